@@ -1,35 +1,103 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
-	"sort"
+	"os"
+	"strings"
 
 	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
 	"github.com/spf13/cobra"
 )
 
 func newCurrentCmd() *cobra.Command {
-	return &cobra.Command{
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
 		Use:   "current",
 		Short: "現在アクティブなツールバージョンを表示",
-		Long: `現在アクティブな全ツールのバージョンを表示します。
+		Long: `カレントディレクトリで有効な全ツールのバージョンを表示します。
+
+バージョンはシェルと同じ順序（ARSENAL_<TOOL>_VERSION、最寄りの .toolversions、
+グローバルの ~/.arsenal/current）で決まり、決定元を合わせて表示します。
+グローバルと異なるバージョンが固定されている場合や、固定されたバージョンが
+インストールされていない場合は色付きで表示します。
 
-symlink で設定されているバージョンを確認できます。
-~/.arsenal/current/ ディレクトリの内容を表示します。`,
+使用例:
+  bastion-arsenal current
+  bastion-arsenal current --json`,
+		Args: cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runCurrent()
+			return runCurrent(jsonOutput)
 		},
 	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "JSON 形式で出力")
+
+	return cmd
 }
 
-func runCurrent() error {
-	// 全ツールの現在のバージョンを取得
-	currentAll, err := manager.CurrentAll()
+// --json の出力
+type currentJSON struct {
+	Tools []currentJSONTool `json:"tools"`
+}
+
+type currentJSONTool struct {
+	Tool      string   `json:"tool"`
+	Version   string   `json:"version"`
+	Source    string   `json:"source"`
+	Origin    string   `json:"origin"`
+	Installed bool     `json:"installed"`
+	Global    string   `json:"global,omitempty"`
+	Mismatch  bool     `json:"mismatch"`
+	Fallbacks []string `json:"fallbacks,omitempty"`
+}
+
+func runCurrent(jsonOutput bool) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("カレントディレクトリ取得エラー: %w", err)
+	}
+
+	// .toolversions が壊れていてもグローバルのバージョンは表示する
+	resolutions, err := manager.Resolve(dir)
+	if resolutions == nil && err != nil {
+		return fmt.Errorf("アクティブバージョン取得エラー: %w", err)
+	}
+	if err != nil {
+		terminal.PrintWarning("%v", err)
+	}
+
+	globals, err := manager.CurrentAll()
 	if err != nil {
 		return fmt.Errorf("アクティブバージョン取得エラー: %w", err)
 	}
 
-	if len(currentAll) == 0 {
+	if jsonOutput {
+		out := currentJSON{Tools: make([]currentJSONTool, 0, len(resolutions))}
+		for _, r := range resolutions {
+			out.Tools = append(out.Tools, currentJSONTool{
+				Tool:      r.Tool,
+				Version:   r.Version,
+				Source:    string(r.Source),
+				Origin:    r.Origin,
+				Installed: r.Installed,
+				Global:    globals[r.Tool],
+				Mismatch:  isGlobalMismatch(r, globals),
+				Fallbacks: r.Fallbacks,
+			})
+		}
+
+		data, err := json.MarshalIndent(out, "", "  ")
+		if err != nil {
+			return fmt.Errorf("JSON 変換エラー: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(resolutions) == 0 {
 		terminal.PrintlnYellow("アクティブなツールがありません")
 		fmt.Println()
 		terminal.PrintlnCyan("ツールをインストールして使用するには:")
@@ -41,24 +109,47 @@ func runCurrent() error {
 	terminal.PrintlnBlue("アクティブなツール:")
 	fmt.Println()
 
-	// ツール名でソート
-	tools := make([]string, 0, len(currentAll))
-	for tool := range currentAll {
-		tools = append(tools, tool)
-	}
-	sort.Strings(tools)
+	// Resolve の結果はツール名順に並んでいる
+	for _, r := range resolutions {
+		ver := terminal.Green(r.Version)
+		if !r.Installed {
+			ver = terminal.Red(r.Version + " (未インストール)")
+		}
+		if len(r.Fallbacks) > 0 {
+			ver += terminal.Cyan(fmt.Sprintf(" (+ %s)", strings.Join(r.Fallbacks, ", ")))
+		}
+		if isGlobalMismatch(r, globals) {
+			ver += terminal.Yellow(fmt.Sprintf(" (グローバル: %s)", globals[r.Tool]))
+		}
 
-	// 表示
-	for _, tool := range tools {
-		version := currentAll[tool]
 		// プラグイン情報を取得して表示名を使う
-		p, err := registry.Get(tool)
-		if err == nil {
-			fmt.Printf("  %s: %s\n", p.DisplayName, terminal.Green(version))
-		} else {
-			fmt.Printf("  %s: %s\n", tool, terminal.Green(version))
+		name := r.Tool
+		if p, err := registry.Get(r.Tool); err == nil {
+			name = p.DisplayName
+		}
+
+		fmt.Printf("  %s: %s  %s\n", name, ver, terminal.Cyan(sourceDescription(r)))
+	}
+
+	var missing []version.Resolution
+	for _, r := range resolutions {
+		if !r.Installed {
+			missing = append(missing, r)
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Println()
+		terminal.PrintlnCyan("未インストールのバージョンをインストールするには:")
+		for _, r := range missing {
+			fmt.Printf("  arsenal install %s %s\n", r.Tool, r.Version)
 		}
 	}
 
 	return nil
 }
+
+// グローバル以外で決まったバージョンがグローバルの current と異なるか判定する
+func isGlobalMismatch(r version.Resolution, globals map[string]string) bool {
+	global, ok := globals[r.Tool]
+	return ok && r.Source != version.SourceGlobal && global != r.Version
+}
@@ -1,8 +1,12 @@
 package cli
 
 import (
+	"bytes"
+	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/arsenal/internal/config"
@@ -43,7 +47,7 @@ func TestRunCurrentEmpty(t *testing.T) {
 	manager = version.NewManager(paths, registry)
 
 	// runCurrent を実行
-	err = runCurrent()
+	err = runCurrent(false)
 	if err != nil {
 		t.Errorf("runCurrent() エラー: %v", err)
 	}
@@ -85,8 +89,61 @@ func TestRunCurrentWithActive(t *testing.T) {
 	manager = version.NewManager(paths, registry)
 
 	// runCurrent を実行
-	err = runCurrent()
+	err = runCurrent(false)
 	if err != nil {
 		t.Errorf("runCurrent() エラー: %v", err)
 	}
 }
+
+// runCurrent --json が決定元と不一致を出力するかテストする
+func TestRunCurrentJSON(t *testing.T) {
+	setupExecTest(t, "22.0.0")
+	if err := os.Symlink(filepath.Join(paths.Versions, "node", "22.0.0"), filepath.Join(paths.Current, "node")); err != nil {
+		t.Fatalf("symlink 作成エラー: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	tvPath := filepath.Join(projectDir, config.ToolVersionFile)
+	if err := os.WriteFile(tvPath, []byte("node 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	// 標準出力をキャプチャ
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runCurrent(true)
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("runCurrent() エラー: %v", err)
+	}
+
+	var out currentJSON
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("JSON パースエラー: %v\n%s", err, buf.String())
+	}
+
+	want := currentJSONTool{
+		Tool:      "node",
+		Version:   "20.10.0",
+		Source:    "toolversions",
+		Origin:    tvPath,
+		Installed: false,
+		Global:    "22.0.0",
+		Mismatch:  true,
+	}
+	if len(out.Tools) != 1 || !reflect.DeepEqual(out.Tools[0], want) {
+		t.Errorf("tools = %+v, want [%+v]", out.Tools, want)
+	}
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/arsenal/internal/terminal"
 	"github.com/arsenal/internal/version"
@@ -15,9 +16,11 @@ func newDoctorCmd() *cobra.Command {
 		Long: `Arsenal の環境設定をチェックします。
 
 以下の項目を確認します:
+  - 設定ファイル（config.toml）の読み込み
   - 必要なディレクトリの存在確認
   - PATH 環境変数の設定確認
-  - インストール済みツールの確認`,
+  - インストール済みツールの確認
+  - シェル設定ファイルの init-shell ブロックが最新か確認`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return runDoctor()
 		},
@@ -29,7 +32,8 @@ func runDoctor() error {
 	fmt.Println()
 
 	// 診断を実行
-	results := manager.Doctor()
+	results := append([]version.DiagResult{checkConfig()}, manager.Doctor()...)
+	results = append(results, checkShellRCFiles()...)
 
 	// 結果を表示
 	hasWarnings := false
@@ -68,3 +72,15 @@ func runDoctor() error {
 
 	return nil
 }
+
+// 設定ファイルを読み込めたか確認する
+func checkConfig() version.DiagResult {
+	name := "設定ファイル"
+	if cfgErr != nil {
+		return version.DiagResult{Name: name, Status: version.StatusError, Message: cfgErr.Error() + " (symlink モードで動作中)"}
+	}
+	if _, err := os.Stat(paths.Config); err != nil {
+		return version.DiagResult{Name: name, Status: version.StatusOK, Message: "なし (デフォルト設定)"}
+	}
+	return version.DiagResult{Name: name, Status: version.StatusOK, Message: fmt.Sprintf("%s (mode: %s)", paths.Config, cfg.Mode)}
+}
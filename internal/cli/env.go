@@ -0,0 +1,327 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// arsenal env がサポートする出力形式
+var envFormats = []string{"sh", "fish", "powershell", "dotenv", "json", "github"}
+
+func newEnvCmd() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "カレントディレクトリのツールの PATH と環境変数を出力",
+		Long: `カレントディレクトリで解決される各ツールのバージョンについて、
+PATH に追加する bin ディレクトリとプラグインの env_vars で定義された
+環境変数を出力します。バージョンは ARSENAL_<TOOL>_VERSION、最寄りの
+.toolversions、グローバルの current の順で決まります。
+
+出力形式:
+  sh          POSIX シェルの export 文（デフォルト）
+  fish        fish の set -gx 文
+  powershell  PowerShell の $env: 代入文
+  dotenv      KEY=VALUE 形式（PATH は展開済みの値）
+  json        ビルドツール向けの JSON
+  github      $GITHUB_PATH / $GITHUB_ENV に追記（GitHub Actions 用）
+
+init-shell が生成するフックはプロンプト表示ごとに hook-env で
+同じ環境変数を設定するため、シェルでは通常直接実行する必要はありません。
+
+使用例:
+  eval "$(bastion-arsenal env)"
+  bastion-arsenal env --format fish | source
+  bastion-arsenal env --format json
+  bastion-arsenal env --format github   # GitHub Actions のステップ内で実行`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runEnv(format)
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "sh", "出力形式 ("+strings.Join(envFormats, ", ")+")")
+
+	return cmd
+}
+
+// json 形式の出力
+type envJSON struct {
+	Tools []envJSONTool     `json:"tools"`
+	Path  []string          `json:"path"`
+	Env   map[string]string `json:"env"`
+}
+
+type envJSONTool struct {
+	Tool      string `json:"tool"`
+	Version   string `json:"version"`
+	Source    string `json:"source"`
+	Installed bool   `json:"installed"`
+}
+
+func runEnv(format string) error {
+	if !slices.Contains(envFormats, format) {
+		return fmt.Errorf("サポートされていない形式: %s (%s のみ対応)", format, strings.Join(envFormats, ", "))
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("カレントディレクトリ取得エラー: %w", err)
+	}
+
+	resolutions, err := manager.Resolve(dir)
+	if err != nil {
+		return fmt.Errorf("バージョン解決エラー: %w", err)
+	}
+
+	binDirs := manager.ResolvedBinDirs(resolutions)
+	vars := manager.ResolvedEnvVars(resolutions)
+	sep := string(os.PathListSeparator)
+
+	switch format {
+	case "sh":
+		if len(binDirs) > 0 {
+			fmt.Printf("export PATH=%s%s\"$PATH\"\n", version.ShellQuote(strings.Join(binDirs, sep)), sep)
+		}
+		for _, v := range vars {
+			fmt.Println(formatExport(format, v.Name, v.Value))
+		}
+
+	case "fish":
+		if len(binDirs) > 0 {
+			quoted := make([]string, 0, len(binDirs))
+			for _, d := range binDirs {
+				quoted = append(quoted, fishQuote(d))
+			}
+			fmt.Printf("set -gx PATH %s $PATH\n", strings.Join(quoted, " "))
+		}
+		for _, v := range vars {
+			fmt.Println(formatExport(format, v.Name, v.Value))
+		}
+
+	case "powershell":
+		if len(binDirs) > 0 {
+			fmt.Printf("$env:PATH = %s + [System.IO.Path]::PathSeparator + $env:PATH\n", psQuote(strings.Join(binDirs, sep)))
+		}
+		for _, v := range vars {
+			fmt.Printf("$env:%s = %s\n", v.Name, psQuote(v.Value))
+		}
+
+	case "dotenv":
+		if len(binDirs) > 0 {
+			path := strings.Join(binDirs, sep)
+			if current := os.Getenv("PATH"); current != "" {
+				path += sep + current
+			}
+			fmt.Printf("PATH=%s\n", dotenvQuote(path))
+		}
+		for _, v := range vars {
+			fmt.Printf("%s=%s\n", v.Name, dotenvQuote(v.Value))
+		}
+
+	case "json":
+		out := envJSON{Tools: []envJSONTool{}, Path: binDirs, Env: make(map[string]string)}
+		if out.Path == nil {
+			out.Path = []string{}
+		}
+		for _, r := range resolutions {
+			out.Tools = append(out.Tools, envJSONTool{Tool: r.Tool, Version: r.Version, Source: string(r.Source), Installed: r.Installed})
+		}
+		for _, v := range vars {
+			out.Env[v.Name] = v.Value
+		}
+
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(out); err != nil {
+			return fmt.Errorf("JSON 出力エラー: %w", err)
+		}
+
+	case "github":
+		return writeGitHubEnv(binDirs, vars)
+	}
+
+	return nil
+}
+
+// GitHub Actions の $GITHUB_PATH / $GITHUB_ENV ファイルに追記する
+func writeGitHubEnv(binDirs []string, vars []version.EnvVar) error {
+	pathFile := os.Getenv("GITHUB_PATH")
+	envFile := os.Getenv("GITHUB_ENV")
+	if pathFile == "" || envFile == "" {
+		return fmt.Errorf("GITHUB_PATH / GITHUB_ENV が設定されていません (GitHub Actions のステップ内で実行してください)")
+	}
+
+	// $GITHUB_PATH の各行は PATH の先頭に追加されるため、優先度の低いものから書く
+	var pathLines strings.Builder
+	for i := len(binDirs) - 1; i >= 0; i-- {
+		pathLines.WriteString(binDirs[i] + "\n")
+	}
+	if err := appendToFile(pathFile, pathLines.String()); err != nil {
+		return fmt.Errorf("%s への書き込みエラー: %w", pathFile, err)
+	}
+
+	var envLines strings.Builder
+	for _, v := range vars {
+		if strings.Contains(v.Value, "\n") {
+			// 複数行の値はヒアドキュメント形式で書く
+			delimiter := "ARSENAL_EOF"
+			for strings.Contains(v.Value, delimiter) {
+				delimiter += "_"
+			}
+			fmt.Fprintf(&envLines, "%s<<%s\n%s\n%s\n", v.Name, delimiter, v.Value, delimiter)
+			continue
+		}
+		fmt.Fprintf(&envLines, "%s=%s\n", v.Name, v.Value)
+	}
+	if err := appendToFile(envFile, envLines.String()); err != nil {
+		return fmt.Errorf("%s への書き込みエラー: %w", envFile, err)
+	}
+
+	terminal.PrintSuccess("PATH に %d 個、環境変数を %d 個追加しました", len(binDirs), len(vars))
+	return nil
+}
+
+// ファイルの末尾に追記する
+func appendToFile(path, content string) error {
+	if content == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// シェル統合が評価するコードの形式（hook-env と shell --emit で使用）
+var shellCodeFormats = []string{"sh", "fish", "powershell", "nu"}
+
+// シェルの種類を評価するコードの形式に変換する
+func shellCodeFormat(shell string) (string, error) {
+	switch shell {
+	case "bash", "zsh":
+		return "sh", nil
+	case "fish", "powershell", "nu":
+		return shell, nil
+	}
+	return "", fmt.Errorf("サポートされていないシェル: %s (bash, zsh, fish, powershell, nu のみ対応)", shell)
+}
+
+// シェルに反映する環境変数の変更 1 件を表す
+type envChange struct {
+	Name  string
+	Value string
+	Path  []string // PATH の場合の各エントリ（fish / nu ではリストとして設定する）
+	Unset bool
+}
+
+// 環境変数の変更を指定形式のシェルコードに変換する
+// nu は文字列を評価できないため、{"set": {...}, "unset": [...]} 形式の JSON を 1 行で返す
+func renderEnvChanges(format string, changes []envChange) []string {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if format == "nu" {
+		out := struct {
+			Set   map[string]interface{} `json:"set"`
+			Unset []string               `json:"unset"`
+		}{Set: make(map[string]interface{}), Unset: []string{}}
+
+		for _, c := range changes {
+			switch {
+			case c.Unset:
+				out.Unset = append(out.Unset, c.Name)
+			case c.Path != nil:
+				out.Set[c.Name] = c.Path
+			default:
+				out.Set[c.Name] = c.Value
+			}
+		}
+
+		data, _ := json.Marshal(out)
+		return []string{string(data)}
+	}
+
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		switch {
+		case c.Unset:
+			lines = append(lines, formatUnset(format, c.Name))
+		case c.Path != nil:
+			lines = append(lines, formatPathExport(format, c.Path))
+		default:
+			lines = append(lines, formatExport(format, c.Name, c.Value))
+		}
+	}
+	return lines
+}
+
+// 環境変数を設定するシェルの文を返す
+func formatExport(format, name, value string) string {
+	switch format {
+	case "fish":
+		return fmt.Sprintf("set -gx %s %s", name, fishQuote(value))
+	case "powershell":
+		return fmt.Sprintf("$env:%s = %s", name, psQuote(value))
+	}
+	return fmt.Sprintf("export %s=%s", name, version.ShellQuote(value))
+}
+
+// 環境変数を削除するシェルの文を返す
+func formatUnset(format, name string) string {
+	switch format {
+	case "fish":
+		return fmt.Sprintf("set -e %s", name)
+	case "powershell":
+		return fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", name)
+	}
+	return fmt.Sprintf("unset %s", name)
+}
+
+// PATH を設定するシェルの文を返す（fish ではリストとして設定する）
+func formatPathExport(format string, dirs []string) string {
+	if format == "fish" {
+		quoted := make([]string, 0, len(dirs))
+		for _, d := range dirs {
+			quoted = append(quoted, fishQuote(d))
+		}
+		return "set -gx PATH " + strings.Join(quoted, " ")
+	}
+	return formatExport(format, "PATH", strings.Join(dirs, string(os.PathListSeparator)))
+}
+
+// PowerShell 用にシングルクォートで囲む
+func psQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// dotenv 用に、必要な場合のみダブルクォートで囲む
+func dotenvQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\n\"'#$\\") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "$", `\$`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// fish 用にシングルクォートで囲む
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "'", `\'`)
+	return "'" + s + "'"
+}
@@ -0,0 +1,199 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/plugin"
+	"github.com/arsenal/internal/version"
+)
+
+// newEnvCmd が正しく作成されるかテストする
+func TestNewEnvCmd(t *testing.T) {
+	cmd := newEnvCmd()
+
+	if cmd.Use != "env" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "env")
+	}
+}
+
+// runEnv がアクティブなツールの環境変数を出力するかテストする
+func TestRunEnv(t *testing.T) {
+	// テスト用の環境をセットアップ
+	tmpDir := t.TempDir()
+	paths := &config.Paths{
+		Root:     filepath.Join(tmpDir, "arsenal"),
+		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
+		Current:  filepath.Join(tmpDir, "arsenal", "current"),
+		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+	}
+
+	if err := paths.EnsureDirs(); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	// env_vars を持つテスト用プラグインを作成
+	pluginContent := `name = "testjava"
+display_name = "Test Java"
+
+[env_vars]
+JAVA_HOME = "{{install_dir}}"
+`
+	if err := os.WriteFile(filepath.Join(paths.Plugins, "testjava.toml"), []byte(pluginContent), 0644); err != nil {
+		t.Fatalf("プラグインファイル作成エラー: %v", err)
+	}
+
+	// アクティブなバージョンを作成
+	versionDir := filepath.Join(paths.Versions, "testjava", "21.0.1")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+	}
+	if err := os.Symlink(versionDir, filepath.Join(paths.Current, "testjava")); err != nil {
+		t.Fatalf("symlink 作成エラー: %v", err)
+	}
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+
+	manager = version.NewManager(paths, registry)
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"sh", "export JAVA_HOME='" + versionDir + "'"},
+		{"sh", "export PATH='" + filepath.Join(versionDir, "bin") + "':\"$PATH\""},
+		{"fish", "set -gx JAVA_HOME '" + versionDir + "'"},
+		{"fish", "set -gx PATH '" + filepath.Join(versionDir, "bin") + "' $PATH"},
+		{"powershell", "$env:JAVA_HOME = '" + versionDir + "'"},
+		{"dotenv", "JAVA_HOME=" + versionDir + "\n"},
+		{"json", `"JAVA_HOME": "` + versionDir + `"`},
+		{"json", `"source": "global"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			// 標準出力をキャプチャ
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := runEnv(tt.format)
+
+			// 標準出力を復元
+			_ = w.Close()
+			os.Stdout = oldStdout
+
+			var buf bytes.Buffer
+			_, _ = io.Copy(&buf, r)
+
+			if err != nil {
+				t.Fatalf("runEnv() エラー: %v", err)
+			}
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("出力に %q が含まれていません: %s", tt.want, buf.String())
+			}
+		})
+	}
+}
+
+// github 形式で $GITHUB_PATH / $GITHUB_ENV に追記されるかテストする
+func TestRunEnvGitHub(t *testing.T) {
+	setupExecTest(t, "20.10.0")
+
+	// env_vars を持つテスト用プラグインを追加
+	pluginContent := `name = "testjava"
+
+[env_vars]
+JAVA_HOME = "{{install_dir}}"
+JAVA_OPTS = "-Xmx1g"
+`
+	if err := os.WriteFile(filepath.Join(paths.Plugins, "testjava.toml"), []byte(pluginContent), 0644); err != nil {
+		t.Fatalf("プラグインファイル作成エラー: %v", err)
+	}
+	javaDir := filepath.Join(paths.Versions, "testjava", "21.0.1")
+	if err := os.MkdirAll(javaDir, 0755); err != nil {
+		t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+	}
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+	manager = version.NewManager(paths, registry)
+
+	t.Setenv("ARSENAL_NODE_VERSION", "20.10.0")
+	t.Setenv("ARSENAL_TESTJAVA_VERSION", "21.0.1")
+
+	githubDir := t.TempDir()
+	pathFile := filepath.Join(githubDir, "path")
+	envFile := filepath.Join(githubDir, "env")
+	if err := os.WriteFile(envFile, []byte("EXISTING=1\n"), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+	t.Setenv("GITHUB_PATH", pathFile)
+	t.Setenv("GITHUB_ENV", envFile)
+
+	// 標準出力を捨てる
+	oldStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	err = runEnv("github")
+	os.Stdout = oldStdout
+	if err != nil {
+		t.Fatalf("runEnv(github) エラー: %v", err)
+	}
+
+	// 後から追加した行ほど PATH の先頭になるため、優先度の高い node が最後になる
+	pathContent, _ := os.ReadFile(pathFile)
+	wantPath := filepath.Join(javaDir, "bin") + "\n" + filepath.Join(paths.Versions, "node", "20.10.0", "bin") + "\n"
+	if string(pathContent) != wantPath {
+		t.Errorf("GITHUB_PATH =\n%s\nwant\n%s", pathContent, wantPath)
+	}
+
+	envContent, _ := os.ReadFile(envFile)
+	wantEnv := "EXISTING=1\nJAVA_HOME=" + javaDir + "\nJAVA_OPTS=-Xmx1g\n"
+	if string(envContent) != wantEnv {
+		t.Errorf("GITHUB_ENV =\n%s\nwant\n%s", envContent, wantEnv)
+	}
+
+	// GitHub Actions の外ではエラー
+	t.Setenv("GITHUB_PATH", "")
+	if err := runEnv("github"); err == nil {
+		t.Error("GITHUB_PATH 未設定でエラーが返されませんでした")
+	}
+}
+
+// dotenv の値が必要な場合のみクォートされるかテストする
+func TestDotenvQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/opt/java", "/opt/java"},
+		{"-Xmx1g -Xms512m", `"-Xmx1g -Xms512m"`},
+		{`say "hi"`, `"say \"hi\""`},
+		{"$HOME", `"\$HOME"`},
+	}
+
+	for _, tt := range tests {
+		if got := dotenvQuote(tt.in); got != tt.want {
+			t.Errorf("dotenvQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// runEnv が不明な形式でエラーを返すかテストする
+func TestRunEnvUnknownFormat(t *testing.T) {
+	if err := runEnv("unknown"); err == nil {
+		t.Error("不明な形式でエラーが返されませんでした")
+	}
+}
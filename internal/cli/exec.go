@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func newExecCmd() *cobra.Command {
+	var install bool
+
+	cmd := &cobra.Command{
+		Use:   "exec <tool>[@<version>]... -- <command> [args...]",
+		Short: "指定バージョンのツールでコマンドを実行",
+		Long: `指定したバージョンのツールを PATH の先頭に置き、プラグインの env_vars を
+設定した環境でコマンドを実行します。グローバルの symlink は変更しません。
+
+バージョンは前方一致で指定でき、一致するインストール済みバージョンのうち
+最新のものが使われます（go@1.21 は 1.21.x の最新）。バージョンを省略すると
+カレントディレクトリで解決されるバージョンが使われます。
+
+コマンドの終了コードとシグナルはそのまま呼び出し元に伝わります。
+
+使用例:
+  arsenal exec node@18.19.0 -- npm test
+  arsenal exec node@18 go@1.21 -- make build
+  arsenal exec --install python@3.12 -- python -m pytest`,
+		Args: cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dash := cmd.ArgsLenAtDash()
+			if dash < 1 || dash == len(args) {
+				return fmt.Errorf("ツールと実行するコマンドを -- で区切って指定してください (例: arsenal exec node@18 -- npm test)")
+			}
+			return runExec(args[:dash], args[dash:], install)
+		},
+	}
+
+	cmd.Flags().BoolVar(&install, "install", false, "未インストールのバージョンを自動でインストール")
+
+	return cmd
+}
+
+func runExec(specs, command []string, install bool) error {
+	resolutions, err := resolveExecTools(specs, install)
+	if err != nil {
+		return err
+	}
+
+	env := manager.ProcessEnv(os.Environ(), resolutions)
+
+	// コマンドは新しい PATH から探す
+	for _, kv := range env {
+		if strings.HasPrefix(kv, "PATH=") {
+			if err := os.Setenv("PATH", strings.TrimPrefix(kv, "PATH=")); err != nil {
+				return err
+			}
+		}
+	}
+	path, err := exec.LookPath(command[0])
+	if err != nil {
+		return fmt.Errorf("コマンドが見つかりません: %s", command[0])
+	}
+
+	return execProcess(path, command, env)
+}
+
+// "tool@version" 形式の指定をインストール済みのバージョンに解決する
+func resolveExecTools(specs []string, install bool) ([]version.Resolution, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("カレントディレクトリ取得エラー: %w", err)
+	}
+
+	resolutions := make([]version.Resolution, 0, len(specs))
+	seen := make(map[string]bool)
+	for _, spec := range specs {
+		tool, verSpec, _ := strings.Cut(spec, "@")
+		if _, err := registry.Get(tool); err != nil {
+			return nil, err
+		}
+		if seen[tool] {
+			return nil, fmt.Errorf("%s が複数回指定されています", tool)
+		}
+		seen[tool] = true
+
+		// バージョン省略時はディレクトリで解決されるバージョンを使う
+		var fallbacks []string
+		if verSpec == "" {
+			r, err := manager.ResolveTool(dir, tool)
+			if err != nil {
+				return nil, err
+			}
+			if r.Version == "" {
+				return nil, fmt.Errorf("%s のバージョンが決まっていません (%s@<version> で指定)", tool, tool)
+			}
+			verSpec = r.Version
+			fallbacks = r.Fallbacks
+		}
+
+		ver, err := manager.FindInstalled(tool, verSpec)
+		if err != nil {
+			return nil, err
+		}
+		if ver == "" {
+			if !install {
+				return nil, fmt.Errorf("%s %s に一致するバージョンがインストールされていません (--install で自動インストール)", tool, verSpec)
+			}
+			if ver, err = installForExec(tool, verSpec); err != nil {
+				return nil, err
+			}
+		}
+
+		resolutions = append(resolutions, version.Resolution{
+			Tool:      tool,
+			Version:   ver,
+			Source:    version.SourceExec,
+			Origin:    spec,
+			Installed: true,
+			Fallbacks: fallbacks,
+		})
+	}
+
+	return resolutions, nil
+}
+
+// 指定に一致する最新のリモートバージョンをインストールする
+// リモート一覧に対応していないツールは指定をそのままバージョンとして扱う
+func installForExec(tool, spec string) (string, error) {
+	// インストールの進捗はコマンドの出力と混ざらないよう標準エラー出力に書き出す
+	ver, err := manager.FindRemote(tool, spec)
+	if err != nil {
+		terminal.FprintWarning(os.Stderr, "リモートのバージョン一覧を取得できません (%v)、%s をそのままインストールします", err, spec)
+		ver = spec
+	}
+
+	if err := manager.InstallWithOutput(tool, ver, os.Stderr); err != nil {
+		return "", err
+	}
+	return ver, nil
+}
@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/plugin"
+	"github.com/arsenal/internal/version"
+)
+
+// exec のテスト環境をセットアップするヘルパー関数
+func setupExecTest(t *testing.T, installed ...string) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	paths = &config.Paths{
+		Root:     filepath.Join(tmpDir, "arsenal"),
+		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
+		Current:  filepath.Join(tmpDir, "arsenal", "current"),
+		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+		Bin:      filepath.Join(tmpDir, "arsenal", "bin"),
+	}
+	if err := paths.EnsureDirs(); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	for _, v := range installed {
+		if err := os.MkdirAll(filepath.Join(paths.Versions, "node", v, "bin"), 0755); err != nil {
+			t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+		}
+	}
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+
+	manager = version.NewManager(paths, registry)
+}
+
+// newExecCmd が -- のない呼び出しを拒否するかテストする
+func TestNewExecCmd(t *testing.T) {
+	cmd := newExecCmd()
+
+	if !strings.HasPrefix(cmd.Use, "exec ") {
+		t.Errorf("Use = %q", cmd.Use)
+	}
+
+	cmd.SetArgs([]string{"node@18", "npm", "test"})
+	cmd.SilenceUsage = true
+	cmd.SilenceErrors = true
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--") {
+		t.Errorf("Execute() = %v, want -- の区切りエラー", err)
+	}
+}
+
+// バージョン指定がインストール済みの最新バージョンに解決されるかテストする
+func TestResolveExecTools(t *testing.T) {
+	setupExecTest(t, "18.19.0", "18.20.1", "20.10.0")
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("node 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	// 作業ディレクトリを変更
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	tests := []struct {
+		spec    string
+		want    string
+		wantErr bool
+	}{
+		{spec: "node@18", want: "18.20.1"},
+		{spec: "node@18.19.0", want: "18.19.0"},
+		{spec: "node", want: "20.10.0"}, // .toolversions のバージョン
+		{spec: "node@16", wantErr: true},
+		{spec: "unknown@1.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			resolutions, err := resolveExecTools([]string{tt.spec}, false)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("resolveExecTools(%q) でエラーが返されませんでした", tt.spec)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveExecTools() エラー: %v", err)
+			}
+			if len(resolutions) != 1 || resolutions[0].Version != tt.want || resolutions[0].Source != version.SourceExec {
+				t.Errorf("resolveExecTools(%q) = %+v, want %s", tt.spec, resolutions, tt.want)
+			}
+		})
+	}
+
+	// 同じツールを複数回指定するとエラー
+	if _, err := resolveExecTools([]string{"node@18", "node@20"}, false); err == nil {
+		t.Error("重複したツール指定でエラーが返されませんでした")
+	}
+}
+
+// グローバルの symlink を変更しないかテストする
+func TestRunExecKeepsCurrent(t *testing.T) {
+	setupExecTest(t, "18.19.0")
+
+	// runExec はコマンド検索のために PATH を書き換えるため、テスト後に復元する
+	t.Setenv("PATH", os.Getenv("PATH"))
+
+	err := runExec([]string{"node@18"}, []string{"arsenal-test-nonexistent-command"}, false)
+	if err == nil || !strings.Contains(err.Error(), "コマンドが見つかりません") {
+		t.Errorf("runExec() = %v, want コマンド未検出エラー", err)
+	}
+
+	if _, err := os.Lstat(filepath.Join(paths.Current, "node")); !os.IsNotExist(err) {
+		t.Error("exec でグローバルの symlink が作成されました")
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package cli
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// 現在のプロセスを指定したコマンドで置き換える
+// 終了コードとシグナルはそのままコマンドが受け取る
+func execProcess(path string, argv, env []string) error {
+	if err := syscall.Exec(path, argv, env); err != nil {
+		return fmt.Errorf("%s の実行エラー: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+)
+
+// Windows ではプロセスを置き換えられないため、子プロセスとして実行して終了コードを引き継ぐ
+func execProcess(path string, argv, env []string) error {
+	cmd := exec.Command(path, argv[1:]...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Ctrl+C は子プロセスにも届くため、親は無視して子の終了を待つ
+	signal.Ignore(os.Interrupt)
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		return fmt.Errorf("%s の実行エラー: %w", path, err)
+	}
+
+	os.Exit(0)
+	return nil
+}
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arsenal/internal/version"
+	"github.com/spf13/cobra"
+)
+
+// hook-env が前回追加した PATH エントリと環境変数名を記録するシェル変数
+const (
+	hookPathVar = "__ARSENAL_HOOK_PATH"
+	hookEnvVar  = "__ARSENAL_HOOK_ENV"
+)
+
+func newHookEnvCmd() *cobra.Command {
+	var shell string
+
+	cmd := &cobra.Command{
+		Use:   "hook-env",
+		Short: "カレントディレクトリに合わせて PATH を更新するシェルコードを出力",
+		Long: `最寄りの .toolversions で固定されたバージョンの bin ディレクトリを
+PATH の先頭に追加し、プロジェクトを離れたら元に戻すシェルコードを出力します。
+
+init-shell が生成するフックがプロンプト表示ごとに評価するため、
+通常は直接実行する必要はありません。`,
+		Hidden: true,
+		Args:   cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runHookEnv(shell)
+		},
+	}
+
+	cmd.Flags().StringVarP(&shell, "shell", "s", "bash", "シェルの種類 (bash, zsh, fish, powershell, nu)")
+
+	return cmd
+}
+
+func runHookEnv(shell string) error {
+	format, err := shellCodeFormat(shell)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("カレントディレクトリ取得エラー: %w", err)
+	}
+
+	// .toolversions が壊れていてもプロンプトは止めず、グローバルの設定で続行する
+	resolutions, err := manager.Resolve(dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "arsenal: %v\n", err)
+	}
+
+	for _, line := range hookEnvLines(format, hookBinDirs(resolutions), manager.ResolvedEnvVars(resolutions)) {
+		fmt.Println(line)
+	}
+
+	return nil
+}
+
+// フックが PATH の先頭に追加するディレクトリを返す
+// shims モードではシムがプロセスごとにバージョンを解決するため、PATH は変えずに env_vars だけ反映する
+func hookBinDirs(resolutions []version.Resolution) []string {
+	if shimsMode() {
+		return nil
+	}
+	return manager.PinnedBinDirs(resolutions)
+}
+
+// 現在の環境との差分だけを反映するシェルの文を返す
+// 変化がなければ何も返さないため、プロンプトごとに評価しても副作用がない
+func hookEnvLines(format string, binDirs []string, vars []version.EnvVar) []string {
+	return renderEnvChanges(format, hookEnvChanges(binDirs, vars))
+}
+
+// 現在の環境と比較して、反映が必要な環境変数の変更を返す
+func hookEnvChanges(binDirs []string, vars []version.EnvVar) []envChange {
+	var changes []envChange
+
+	// 前回追加したエントリを取り除いてから、今回のディレクトリを先頭に追加する
+	currentPath := os.Getenv("PATH")
+	prevDirs := splitPathList(os.Getenv(hookPathVar))
+	newPath := append(append([]string{}, binDirs...), removePathEntries(splitPathList(currentPath), prevDirs)...)
+
+	if joined := strings.Join(newPath, string(os.PathListSeparator)); joined != currentPath {
+		changes = append(changes, envChange{Name: "PATH", Path: newPath})
+	}
+	changes = append(changes, trackerChange(hookPathVar, binDirs)...)
+
+	// 今回設定しない変数のうち、前回フックが設定したものは削除する
+	names := make([]string, 0, len(vars))
+	setNames := make(map[string]bool)
+	for _, v := range vars {
+		if setNames[v.Name] {
+			continue
+		}
+		setNames[v.Name] = true
+		names = append(names, v.Name)
+		if value, ok := os.LookupEnv(v.Name); !ok || value != v.Value {
+			changes = append(changes, envChange{Name: v.Name, Value: v.Value})
+		}
+	}
+	for _, name := range splitPathList(os.Getenv(hookEnvVar)) {
+		if !setNames[name] {
+			changes = append(changes, envChange{Name: name, Unset: true})
+		}
+	}
+	changes = append(changes, trackerChange(hookEnvVar, names)...)
+
+	return changes
+}
+
+// フックの状態を記録する変数の変更を返す（変化がなければ空）
+func trackerChange(name string, values []string) []envChange {
+	joined := strings.Join(values, string(os.PathListSeparator))
+	if joined == os.Getenv(name) {
+		return nil
+	}
+	if joined == "" {
+		return []envChange{{Name: name, Unset: true}}
+	}
+	return []envChange{{Name: name, Value: joined}}
+}
+
+// 空文字列を空のリストとして扱う filepath.SplitList
+func splitPathList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return filepath.SplitList(s)
+}
+
+// entries から remove の各要素を 1 件ずつ取り除く
+func removePathEntries(entries, remove []string) []string {
+	pending := make(map[string]int)
+	for _, r := range remove {
+		pending[r]++
+	}
+
+	result := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if pending[e] > 0 {
+			pending[e]--
+			continue
+		}
+		result = append(result, e)
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/arsenal/internal/version"
+)
+
+// newHookEnvCmd が正しく作成されるかテストする
+func TestNewHookEnvCmd(t *testing.T) {
+	cmd := newHookEnvCmd()
+
+	if cmd.Use != "hook-env" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "hook-env")
+	}
+	if !cmd.Hidden {
+		t.Error("hook-env はヘルプに表示されない内部コマンドです")
+	}
+}
+
+// プロジェクトへの出入りで PATH と環境変数が追加・復元されるかテストする
+func TestHookEnvLines(t *testing.T) {
+	sep := string(os.PathListSeparator)
+	vars := []version.EnvVar{{Tool: "go", Name: "GOROOT", Value: "/v/go/1.22.0"}}
+
+	// プロジェクトに入る
+	t.Setenv("PATH", "/usr/bin"+sep+"/bin")
+	t.Setenv(hookPathVar, "")
+	t.Setenv(hookEnvVar, "")
+	_ = os.Unsetenv("GOROOT")
+
+	lines := hookEnvLines("sh", []string{"/v/node/18/bin"}, vars)
+	want := []string{
+		"export PATH='/v/node/18/bin" + sep + "/usr/bin" + sep + "/bin'",
+		"export " + hookPathVar + "='/v/node/18/bin'",
+		"export GOROOT='/v/go/1.22.0'",
+		"export " + hookEnvVar + "='GOROOT'",
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("入るときの出力 =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	// 評価後の状態では何も出力しない
+	t.Setenv("PATH", "/v/node/18/bin"+sep+"/usr/bin"+sep+"/bin")
+	t.Setenv(hookPathVar, "/v/node/18/bin")
+	t.Setenv(hookEnvVar, "GOROOT")
+	t.Setenv("GOROOT", "/v/go/1.22.0")
+
+	if lines := hookEnvLines("sh", []string{"/v/node/18/bin"}, vars); len(lines) != 0 {
+		t.Errorf("変化がないのに出力されました: %v", lines)
+	}
+
+	// プロジェクトを離れる
+	lines = hookEnvLines("fish", nil, nil)
+	want = []string{
+		"set -gx PATH '/usr/bin' '/bin'",
+		"set -e " + hookPathVar,
+		"set -e GOROOT",
+		"set -e " + hookEnvVar,
+	}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("離れるときの出力 =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// runHookEnv が不明なシェルでエラーを返すかテストする
+func TestRunHookEnvUnknownShell(t *testing.T) {
+	if err := runHookEnv("unknown"); err == nil {
+		t.Error("存在しないシェルでエラーが返されませんでした")
+	}
+}
@@ -0,0 +1,147 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func newInitCmd() *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "プロジェクトのファイルから .toolversions を作成",
+		Long: `現在のディレクトリのファイルから使われているツールとバージョンの要件を検出し、
+具体的なバージョンを選んで .toolversions を作成します。
+
+検出するファイル:
+  package.json        engines.node
+  go.mod              toolchain 行、なければ go 行
+  pyproject.toml      requires-python（Poetry の python 依存）
+  .terraform-version  バージョン
+
+バージョンは要件に一致する有効なバージョン（グローバルの current）を優先し、
+なければリモートの一覧（ls-remote）から一致する最新、取得できなければ
+インストール済みの最新を選びます。
+
+作成する内容を表示して確認します。--yes を指定すると確認しません。
+既に .toolversions がある場合は何もしません。
+
+使用例:
+  arsenal init
+  arsenal init --yes`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInit(yes, cmd.InOrStdin())
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "確認せずに作成")
+
+	return cmd
+}
+
+// .toolversions に書き込むツール 1 件
+type initEntry struct {
+	detected version.DetectedTool
+	version  string
+	reason   string
+}
+
+func runInit(yes bool, in io.Reader) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("カレントディレクトリ取得エラー: %w", err)
+	}
+
+	path := filepath.Join(cwd, config.ToolVersionFile)
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s は既にあります (bastion-arsenal use --local <tool> <version> で追加)", path)
+	}
+
+	detected, warnings, err := version.DetectTools(cwd)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		terminal.PrintWarning("%s", w)
+	}
+
+	var entries []initEntry
+	for _, d := range detected {
+		if _, err := registry.Get(d.Tool); err != nil {
+			terminal.PrintWarning("%s を検出しましたが %s プラグインがありません (bastion-arsenal plugin add で追加)", d.Source, d.Tool)
+			continue
+		}
+
+		ver, reason, err := manager.ProposeVersion(d)
+		if err != nil {
+			terminal.PrintWarning("%s: %v", d.Source, err)
+			continue
+		}
+		entries = append(entries, initEntry{detected: d, version: ver, reason: reason})
+	}
+
+	if len(entries) == 0 {
+		return fmt.Errorf("バージョンを決められるツールが見つかりませんでした")
+	}
+
+	width := 0
+	for _, e := range entries {
+		if len(e.detected.Tool) > width {
+			width = len(e.detected.Tool)
+		}
+	}
+
+	terminal.PrintlnBlue("検出したツール:")
+	fmt.Println()
+	for _, e := range entries {
+		fmt.Printf("  %-*s  %s  %s\n", width, e.detected.Tool, terminal.Green(e.version),
+			terminal.Cyan(fmt.Sprintf("%s: %s (%s)", e.detected.Source, e.detected.Constraint, e.reason)))
+	}
+	fmt.Println()
+
+	if !yes && !confirm(in, fmt.Sprintf("%s を作成しますか?", path)) {
+		terminal.PrintlnYellow("中止しました")
+		return nil
+	}
+
+	if err := os.WriteFile(path, initToolVersionsContent(entries), 0644); err != nil {
+		return fmt.Errorf("%s 書き込みエラー: %w", path, err)
+	}
+
+	terminal.PrintSuccess("%s を作成しました", path)
+	fmt.Println("  bastion-arsenal sync でインストールできます")
+	return nil
+}
+
+// 検出元を行末コメントにした .toolversions の内容を返す
+func initToolVersionsContent(entries []initEntry) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("# bastion-arsenal init が検出したバージョン\n")
+	for _, e := range entries {
+		fmt.Fprintf(&buf, "%s %s # %s: %s\n", e.detected.Tool, e.version, e.detected.Source, e.detected.Constraint)
+	}
+	return buf.Bytes()
+}
+
+// y / yes の入力で true を返す（それ以外や入力の終わりは false）
+func confirm(in io.Reader, question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
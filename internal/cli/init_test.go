@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runInit が確認の結果に従って .toolversions を作成するかテストする
+func TestRunInit(t *testing.T) {
+	setupExecTest(t, "20.10.0")
+	if err := manager.Use("node", "20.10.0"); err != nil {
+		t.Fatalf("Use() エラー: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	files := map[string]string{
+		"package.json": `{"engines": {"node": ">=18"}}`,
+		// terraform プラグインはないため書き込まない
+		".terraform-version": "1.7.0\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("テストファイル作成エラー: %v", err)
+		}
+	}
+
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	tvPath := filepath.Join(projectDir, ".toolversions")
+
+	// 確認で n を答えると作成しない
+	if err := runInit(false, strings.NewReader("n\n")); err != nil {
+		t.Fatalf("runInit() エラー: %v", err)
+	}
+	if _, err := os.Stat(tvPath); !os.IsNotExist(err) {
+		t.Fatal("確認を拒否したのに .toolversions が作成されました")
+	}
+
+	// --yes では確認しない
+	if err := runInit(true, strings.NewReader("")); err != nil {
+		t.Fatalf("runInit() エラー: %v", err)
+	}
+	content, err := os.ReadFile(tvPath)
+	if err != nil {
+		t.Fatalf(".toolversions 読み込みエラー: %v", err)
+	}
+	expected := "# bastion-arsenal init が検出したバージョン\nnode 20.10.0 # package.json の engines.node: >=18\n"
+	if string(content) != expected {
+		t.Errorf(".toolversions の内容が正しくありません\ngot:  %q\nwant: %q", string(content), expected)
+	}
+
+	// 既にあれば上書きしない
+	if err := runInit(true, strings.NewReader("")); err == nil {
+		t.Error(".toolversions があるのにエラーが返されませんでした")
+	}
+}
@@ -2,56 +2,343 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/arsenal/internal/terminal"
 	"github.com/spf13/cobra"
 )
 
+// init-shell が対応するシェル
+var initShells = []string{"bash", "zsh", "fish", "powershell", "nu"}
+
 func newInitShellCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "init-shell [bash|zsh|fish]",
+	var install, uninstall bool
+	var rcFile string
+
+	cmd := &cobra.Command{
+		Use:   "init-shell [bash|zsh|fish|powershell|nu]",
 		Short: "シェル設定スクリプトを生成",
 		Long: `指定したシェル用の初期化スクリプトを生成します。
 
-このコマンドの出力をシェルの設定ファイルに追加してください。
+スクリプトはプロンプト表示ごとに最寄りの .toolversions を確認し、
+固定されたバージョンの bin ディレクトリを PATH の先頭に追加します。
+config.toml で mode = "shims" を設定している場合は ~/.arsenal/shims を
+PATH に追加し、フックはプラグインの環境変数だけを更新します。
+
+--install を指定するとシェルの設定ファイルにスクリプトを書き込みます。
+スクリプトはマーカーコメントで囲まれ、再実行時はその部分だけが置き換わります。
+書き換え前の内容は <設定ファイル>.arsenal.bak に保存されます。
+
+  bash        ~/.bashrc
+  zsh         $ZDOTDIR/.zshrc (未設定なら ~/.zshrc)
+  fish        ~/.config/fish/config.fish
+  powershell  $PROFILE の既定の場所
+  nu          $nu.config-path の既定の場所
 
 使用例:
-  # Bash の場合 (~/.bashrc に追加)
-  bastion-arsenal init-shell bash >> ~/.bashrc
+  # 設定ファイルに書き込む（再実行しても重複しない）
+  bastion-arsenal init-shell bash --install
 
-  # Zsh の場合 (~/.zshrc に追加)
-  bastion-arsenal init-shell zsh >> ~/.zshrc
+  # 書き込んだスクリプトを取り除く
+  bastion-arsenal init-shell bash --uninstall
 
-  # Fish の場合 (~/.config/fish/config.fish に追加)
-  bastion-arsenal init-shell fish >> ~/.config/fish/config.fish`,
+  # 出力を直接評価する場合
+  eval "$(bastion-arsenal init-shell bash)"
+  bastion-arsenal init-shell fish | source
+  bastion-arsenal init-shell powershell | Out-String | Invoke-Expression`,
 		Args:      cobra.ExactArgs(1),
-		ValidArgs: []string{"bash", "zsh", "fish"},
+		ValidArgs: initShells,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case install:
+				return runInitShellInstall(args[0], rcFile)
+			case uninstall:
+				return runInitShellUninstall(args[0], rcFile)
+			}
 			return runInitShell(args[0])
 		},
 	}
+
+	cmd.Flags().BoolVar(&install, "install", false, "シェルの設定ファイルにスクリプトを書き込む")
+	cmd.Flags().BoolVar(&uninstall, "uninstall", false, "シェルの設定ファイルからスクリプトを取り除く")
+	cmd.Flags().StringVar(&rcFile, "rc-file", "", "書き込む設定ファイル（省略時はシェルごとの既定の場所）")
+	cmd.MarkFlagsMutuallyExclusive("install", "uninstall")
+
+	return cmd
 }
 
 func runInitShell(shell string) error {
-	arsenalDir := paths.Root
+	script, err := initScript(shell)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(script)
+	return nil
+}
+
+func runInitShellInstall(shell, rcFile string) error {
+	block, err := rcBlock(shell)
+	if err != nil {
+		return err
+	}
+
+	if rcFile == "" {
+		rcFile, err = shellRCFile(shell)
+		if err != nil {
+			return err
+		}
+	}
+
+	existed := fileExists(rcFile)
+	changed, err := installRCBlock(rcFile, block)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		terminal.PrintInfo("%s は既に最新です", rcFile)
+		return nil
+	}
+
+	terminal.PrintSuccess("%s に Arsenal の設定を書き込みました", rcFile)
+	if existed {
+		fmt.Printf("  バックアップ: %s.arsenal.bak\n", rcFile)
+	}
+	terminal.PrintlnCyan("新しいシェルを開くと有効になります")
+	return nil
+}
+
+func runInitShellUninstall(shell, rcFile string) error {
+	var err error
+	if rcFile == "" {
+		rcFile, err = shellRCFile(shell)
+		if err != nil {
+			return err
+		}
+	}
+
+	removed, err := uninstallRCBlock(rcFile)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		terminal.PrintInfo("%s に Arsenal の設定はありません", rcFile)
+		return nil
+	}
+
+	terminal.PrintSuccess("%s から Arsenal の設定を取り除きました", rcFile)
+	fmt.Printf("  バックアップ: %s.arsenal.bak\n", rcFile)
+	return nil
+}
+
+// 指定したシェルの初期化スクリプトを返す
+// PATH の追加、ディレクトリフック、arsenal shell 用のラッパー関数、補完の順に並ぶ
+// shims モードでもフックはプラグインの env_vars を反映するために入れる（PATH は hook-env が変えない）
+func initScript(shell string) (string, error) {
+	var b strings.Builder
+
+	// symlink モードではアクティブな全ツールの実行ファイルへの symlink、
+	// shims モードではシムが置かれるディレクトリを PATH に追加する
+	dir := paths.Bin
+	header := "# Arsenal の初期化"
+	if shimsMode() {
+		dir = paths.Shims
+		header += " (shims モード)"
+	}
+	b.WriteString(header + "\n")
 
 	switch shell {
 	case "bash", "zsh":
-		fmt.Println("# Arsenal の初期化")
-		fmt.Printf("export PATH=\"%s/current/*/bin:$PATH\"\n", arsenalDir)
-		fmt.Println()
-		fmt.Println("# Arsenal の補完を有効化")
-		fmt.Printf("eval \"$(bastion-arsenal completion %s)\"\n", shell)
+		fmt.Fprintf(&b, "export PATH=\"%s:$PATH\"\n", dir)
+		b.WriteString("\n")
+		if shell == "bash" {
+			b.WriteString(bashHook)
+		} else {
+			b.WriteString(zshHook)
+		}
+		b.WriteString("\n")
+		b.WriteString(posixShellWrapper)
+		b.WriteString("\n")
+		b.WriteString("# Arsenal の補完を有効化\n")
+		fmt.Fprintf(&b, "eval \"$(bastion-arsenal completion %s)\"\n", shell)
 
 	case "fish":
-		fmt.Println("# Arsenal の初期化")
-		fmt.Printf("set -gx PATH %s/current/*/bin $PATH\n", arsenalDir)
-		fmt.Println()
-		fmt.Println("# Arsenal の補完を有効化")
-		fmt.Println("bastion-arsenal completion fish | source")
+		fmt.Fprintf(&b, "set -gx PATH %s $PATH\n", dir)
+		b.WriteString("\n")
+		b.WriteString(fishHook)
+		b.WriteString("\n")
+		b.WriteString(fishShellWrapper)
+		b.WriteString("\n")
+		b.WriteString("# Arsenal の補完を有効化\n")
+		b.WriteString("bastion-arsenal completion fish | source\n")
+
+	case "powershell":
+		// ラッパー関数は同名のため、実行ファイルのパスを先に解決しておく
+		b.WriteString("$global:__arsenal_exe = (Get-Command bastion-arsenal -CommandType Application | Select-Object -First 1).Source\n")
+		// プロファイルを読み直しても PATH に同じエントリを重ねない
+		fmt.Fprintf(&b, "if (($env:PATH -split [System.IO.Path]::PathSeparator) -notcontains %s) {\n", psQuote(dir))
+		fmt.Fprintf(&b, "    $env:PATH = %s + [System.IO.Path]::PathSeparator + $env:PATH\n", psQuote(dir))
+		b.WriteString("}\n")
+		b.WriteString("\n")
+		b.WriteString(powershellHook)
+		b.WriteString("\n")
+		b.WriteString(powershellShellWrapper)
+		b.WriteString("\n")
+		b.WriteString("# Arsenal の補完を有効化\n")
+		b.WriteString("& $global:__arsenal_exe completion powershell | Out-String | Invoke-Expression\n")
+
+	case "nu":
+		fmt.Fprintf(&b, "$env.PATH = ($env.PATH | split row (char esep) | prepend %s)\n", nuQuote(dir))
+		b.WriteString("\n")
+		b.WriteString(nuApplyEnv)
+		b.WriteString("\n")
+		b.WriteString(nuHook)
+		b.WriteString("\n")
+		b.WriteString(nuShellWrapper)
+		b.WriteString("\n")
+		b.WriteString(nuCompleter)
 
 	default:
-		return fmt.Errorf("サポートされていないシェル: %s (bash, zsh, fish のみ対応)", shell)
+		return "", fmt.Errorf("サポートされていないシェル: %s (%s のみ対応)", shell, strings.Join(initShells, ", "))
 	}
 
-	return nil
+	return b.String(), nil
+}
+
+// nushell 用にシングルクォートで囲む（シングルクォートを含む場合は raw 文字列にする）
+func nuQuote(s string) string {
+	if !strings.Contains(s, "'") {
+		return "'" + s + "'"
+	}
+	return "r#'" + s + "'#"
+}
+
+const bashHook = `# .toolversions に合わせて PATH と環境変数をプロンプトごとに更新
+_arsenal_hook() {
+  local previous_exit_status=$?
+  eval "$(command bastion-arsenal hook-env --shell bash)"
+  return $previous_exit_status
+}
+if [[ ";${PROMPT_COMMAND:-};" != *";_arsenal_hook;"* ]]; then
+  PROMPT_COMMAND="_arsenal_hook${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+fi
+`
+
+const zshHook = `# .toolversions に合わせて PATH と環境変数をディレクトリ移動時とプロンプトごとに更新
+_arsenal_hook() {
+  eval "$(command bastion-arsenal hook-env --shell zsh)"
+}
+typeset -ag precmd_functions chpwd_functions
+if (( ! ${precmd_functions[(I)_arsenal_hook]} )); then
+  precmd_functions=(_arsenal_hook $precmd_functions)
+fi
+if (( ! ${chpwd_functions[(I)_arsenal_hook]} )); then
+  chpwd_functions=(_arsenal_hook $chpwd_functions)
+fi
+`
+
+const fishHook = `# .toolversions に合わせて PATH と環境変数をディレクトリ移動時とプロンプトごとに更新
+function __arsenal_hook --on-variable PWD --on-event fish_prompt
+    command bastion-arsenal hook-env --shell fish | source
+end
+__arsenal_hook
+`
+
+const powershellHook = `# .toolversions に合わせて PATH と環境変数をプロンプトごとに更新
+function global:__arsenal_hook {
+    $code = & $global:__arsenal_exe hook-env --shell powershell | Out-String
+    if ($code.Trim()) { Invoke-Expression $code }
+}
+if (-not $global:__arsenal_original_prompt) {
+    $global:__arsenal_original_prompt = $function:prompt
+    function global:prompt {
+        $previousExitCode = $global:LASTEXITCODE
+        __arsenal_hook
+        $global:LASTEXITCODE = $previousExitCode
+        & $global:__arsenal_original_prompt
+    }
+}
+`
+
+const nuApplyEnv = `# arsenal が出力する {"set": {...}, "unset": [...]} を現在の環境に反映
+def --env __arsenal_apply [changes: string] {
+    if ($changes | str trim | is-empty) { return }
+    let parsed = ($changes | from json)
+    for name in $parsed.unset { hide-env --ignore-errors $name }
+    load-env $parsed.set
+}
+`
+
+const nuHook = `# .toolversions に合わせて PATH と環境変数をプロンプトごとに更新
+$env.config = ($env.config | upsert hooks.pre_prompt (
+    ($env.config.hooks.pre_prompt? | default []) | append {||
+        __arsenal_apply (^bastion-arsenal hook-env --shell nu)
+    }
+))
+`
+
+const posixShellWrapper = `# arsenal shell で現在のシェルのバージョンを切り替えるためのラッパー
+bastion-arsenal() {
+  if [ "$1" = "shell" ]; then
+    shift
+    local __arsenal_out
+    __arsenal_out="$(command bastion-arsenal shell --emit sh "$@")" || return $?
+    eval "$__arsenal_out"
+  else
+    command bastion-arsenal "$@"
+  fi
+}
+`
+
+const fishShellWrapper = `# arsenal shell で現在のシェルのバージョンを切り替えるためのラッパー
+function bastion-arsenal
+    if test (count $argv) -gt 0; and test "$argv[1]" = shell
+        set -l __arsenal_out (command bastion-arsenal shell --emit fish $argv[2..-1]); or return $status
+        string join \n -- $__arsenal_out | source
+    else
+        command bastion-arsenal $argv
+    end
+end
+`
+
+const powershellShellWrapper = `# arsenal shell で現在のシェルのバージョンを切り替えるためのラッパー
+function global:bastion-arsenal {
+    if ($args.Count -gt 0 -and $args[0] -eq 'shell') {
+        $rest = @($args | Select-Object -Skip 1)
+        $code = & $global:__arsenal_exe shell --emit powershell @rest | Out-String
+        if ($LASTEXITCODE -eq 0 -and $code.Trim()) { Invoke-Expression $code }
+    } else {
+        & $global:__arsenal_exe @args
+    }
+}
+`
+
+// nushell は cobra の補完スクリプトを読めないため、外部コマンドの補完から __complete を呼ぶ
+// __complete は "候補\t説明" の行と ":<directive>" の行を出力する
+const nuCompleter = `# Arsenal の補完を有効化（bastion-arsenal 以外は元の補完に任せる）
+let __arsenal_previous_completer = ($env.config.completions.external.completer? | default null)
+$env.config.completions.external.enable = true
+$env.config.completions.external.completer = {|spans|
+    if ($spans | get 0? | default "") == "bastion-arsenal" {
+        ^bastion-arsenal __complete ...($spans | skip 1)
+            | lines
+            | where {|line| not ($line | str starts-with ":") }
+            | each {|line|
+                let parts = ($line | split row "\t")
+                {value: $parts.0, description: ($parts | skip 1 | str join "\t")}
+            }
+    } else if $__arsenal_previous_completer != null {
+        do $__arsenal_previous_completer $spans
+    }
+}
+`
+
+const nuShellWrapper = `# arsenal shell で現在のシェルのバージョンを切り替えるためのラッパー
+def --env --wrapped bastion-arsenal [...args] {
+    if ($args | get 0? | default "") == "shell" {
+        __arsenal_apply (^bastion-arsenal shell --emit nu ...($args | skip 1))
+    } else {
+        ^bastion-arsenal ...$args
+    }
 }
+`
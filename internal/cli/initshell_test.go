@@ -2,6 +2,7 @@ package cli
 
 import (
 	"bytes"
+	"flag"
 	"io"
 	"os"
 	"path/filepath"
@@ -13,12 +14,15 @@ import (
 	"github.com/arsenal/internal/version"
 )
 
+// golden ファイルを現在の出力で更新する (go test ./internal/cli -run TestInitScriptGolden -update)
+var updateGolden = flag.Bool("update", false, "golden ファイルを更新する")
+
 // newInitShellCmd が正しく作成されるかテストする
 func TestNewInitShellCmd(t *testing.T) {
 	cmd := newInitShellCmd()
 
-	if cmd.Use != "init-shell [bash|zsh|fish]" {
-		t.Errorf("Use = %q, want %q", cmd.Use, "init-shell [bash|zsh|fish]")
+	if cmd.Use != "init-shell [bash|zsh|fish|powershell|nu]" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "init-shell [bash|zsh|fish|powershell|nu]")
 	}
 }
 
@@ -31,15 +35,24 @@ func TestRunInitShellBash(t *testing.T) {
 		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
 		Current:  filepath.Join(tmpDir, "arsenal", "current"),
 		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+		Bin:      filepath.Join(tmpDir, "arsenal", "bin"),
 	}
 
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+
+	manager = version.NewManager(paths, registry)
+
 	// 標準出力をキャプチャ
 	oldStdout := os.Stdout
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
 	// runInitShell を実行
-	err := runInitShell("bash")
+	err = runInitShell("bash")
 	if err != nil {
 		t.Errorf("runInitShell() エラー: %v", err)
 	}
@@ -59,9 +72,21 @@ func TestRunInitShellBash(t *testing.T) {
 	if !strings.Contains(output, paths.Root) {
 		t.Errorf("Arsenal のパスが含まれていません: %s", paths.Root)
 	}
+	if !strings.Contains(output, `export PATH="`+paths.Bin+`:$PATH"`) {
+		t.Error("bin ディレクトリが PATH に含まれていません")
+	}
 	if !strings.Contains(output, "bastion-arsenal completion bash") {
 		t.Error("補完スクリプトが含まれていません")
 	}
+	if !strings.Contains(output, "hook-env --shell bash") {
+		t.Error("hook-env の呼び出しが含まれていません")
+	}
+	if !strings.Contains(output, "PROMPT_COMMAND=") {
+		t.Error("PROMPT_COMMAND へのフック登録が含まれていません")
+	}
+	if !strings.Contains(output, `command bastion-arsenal shell --emit sh "$@"`) {
+		t.Error("arsenal shell 用のラッパー関数が含まれていません")
+	}
 }
 
 // runInitShell が zsh スクリプトを生成するかテストする
@@ -73,15 +98,24 @@ func TestRunInitShellZsh(t *testing.T) {
 		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
 		Current:  filepath.Join(tmpDir, "arsenal", "current"),
 		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+		Bin:      filepath.Join(tmpDir, "arsenal", "bin"),
+	}
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
 	}
 
+	manager = version.NewManager(paths, registry)
+
 	// 標準出力をキャプチャ
 	oldStdout := os.Stdout
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
 	// runInitShell を実行
-	err := runInitShell("zsh")
+	err = runInitShell("zsh")
 	if err != nil {
 		t.Errorf("runInitShell() エラー: %v", err)
 	}
@@ -101,6 +135,9 @@ func TestRunInitShellZsh(t *testing.T) {
 	if !strings.Contains(output, "bastion-arsenal completion zsh") {
 		t.Error("補完スクリプトが含まれていません")
 	}
+	if !strings.Contains(output, "chpwd_functions=(_arsenal_hook") || !strings.Contains(output, "precmd_functions=(_arsenal_hook") {
+		t.Error("chpwd/precmd へのフック登録が含まれていません")
+	}
 }
 
 // runInitShell が fish スクリプトを生成するかテストする
@@ -112,15 +149,24 @@ func TestRunInitShellFish(t *testing.T) {
 		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
 		Current:  filepath.Join(tmpDir, "arsenal", "current"),
 		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+		Bin:      filepath.Join(tmpDir, "arsenal", "bin"),
 	}
 
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+
+	manager = version.NewManager(paths, registry)
+
 	// 標準出力をキャプチャ
 	oldStdout := os.Stdout
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
 	// runInitShell を実行
-	err := runInitShell("fish")
+	err = runInitShell("fish")
 	if err != nil {
 		t.Errorf("runInitShell() エラー: %v", err)
 	}
@@ -140,6 +186,15 @@ func TestRunInitShellFish(t *testing.T) {
 	if !strings.Contains(output, "bastion-arsenal completion fish") {
 		t.Error("補完スクリプトが含まれていません")
 	}
+	if !strings.Contains(output, "--on-variable PWD") {
+		t.Error("PWD の変更フックが含まれていません")
+	}
+	if !strings.Contains(output, "hook-env --shell fish | source") {
+		t.Error("hook-env の呼び出しが含まれていません")
+	}
+	if !strings.Contains(output, "shell --emit fish") {
+		t.Error("arsenal shell 用のラッパー関数が含まれていません")
+	}
 }
 
 // runInitShell が不明なシェルでエラーを返すかテストする
@@ -151,6 +206,7 @@ func TestRunInitShellUnknownShell(t *testing.T) {
 		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
 		Current:  filepath.Join(tmpDir, "arsenal", "current"),
 		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+		Bin:      filepath.Join(tmpDir, "arsenal", "bin"),
 	}
 
 	var err error
@@ -167,3 +223,46 @@ func TestRunInitShellUnknownShell(t *testing.T) {
 		t.Error("存在しないシェルでエラーが返されませんでした")
 	}
 }
+
+// 各シェル・各モードの初期化スクリプトが golden ファイルと一致するかテストする
+func TestInitScriptGolden(t *testing.T) {
+	// golden ファイルに環境依存のパスが入らないよう固定のパスを使う
+	paths = &config.Paths{
+		Root:  "/home/user/.arsenal",
+		Bin:   "/home/user/.arsenal/bin",
+		Shims: "/home/user/.arsenal/shims",
+	}
+	defer func() { cfg = nil }()
+
+	for _, mode := range []string{config.ModeSymlink, config.ModeShims} {
+		cfg = &config.Config{Mode: mode}
+
+		for _, shell := range initShells {
+			name := mode + "-" + shell
+			t.Run(name, func(t *testing.T) {
+				got, err := initScript(shell)
+				if err != nil {
+					t.Fatalf("initScript(%q) エラー: %v", shell, err)
+				}
+
+				golden := filepath.Join("testdata", "init-shell", name+".golden")
+				if *updateGolden {
+					if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+						t.Fatalf("ディレクトリ作成エラー: %v", err)
+					}
+					if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+						t.Fatalf("golden ファイル書き込みエラー: %v", err)
+					}
+				}
+
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("golden ファイル読み込みエラー (-update で作成): %v", err)
+				}
+				if got != string(want) {
+					t.Errorf("%s と出力が一致しません (-update で更新)\n--- got ---\n%s", golden, got)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arsenal/internal/terminal"
+	"github.com/spf13/cobra"
+)
+
+func newLockCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "lock",
+		Short: ".toolversions.lock を生成",
+		Long: `.toolversions の各バージョン指定を正確なバージョンに解決し、
+プラグインが対応する全プラットフォームのダウンロード URL と
+アーカイブの SHA-256 を .toolversions.lock に記録します。
+
+既にロックされている指定はそのバージョンを保ちます。
+バージョンを更新するには .toolversions.lock から該当するエントリを削除してください。
+記録済みの URL のチェックサムは再計算しません。
+
+使用例:
+  arsenal lock
+  arsenal sync --frozen   # 別のマシンでロックファイルの通りにインストール`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runLock()
+		},
+	}
+}
+
+func runLock() error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("カレントディレクトリ取得エラー: %w", err)
+	}
+
+	path, err := manager.Lock(cwd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println()
+	terminal.PrintSuccess("%s を更新しました", path)
+	return nil
+}
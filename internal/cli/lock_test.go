@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/plugin"
+	"github.com/arsenal/internal/version"
+)
+
+// newLockCmd が正しく作成されるかテストする
+func TestNewLockCmd(t *testing.T) {
+	cmd := newLockCmd()
+
+	if cmd.Use != "lock" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "lock")
+	}
+}
+
+// runLock がロックファイルを作成するかテストする
+func TestRunLock(t *testing.T) {
+	// テスト用の HTTP サーバーを起動（ダミーのアーカイブを返す）
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("archive"))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	paths = &config.Paths{
+		Root:     filepath.Join(tmpDir, "arsenal"),
+		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
+		Current:  filepath.Join(tmpDir, "arsenal", "current"),
+		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+	}
+
+	if err := paths.EnsureDirs(); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	// テスト用プラグイン定義を作成
+	pluginContent := `name = "testnode"
+download_url = "` + server.URL + `/node-v{{version}}-{{os}}.tar.gz"
+
+[os_map]
+linux = "linux"
+
+[arch_map]
+amd64 = "x64"
+`
+	if err := os.WriteFile(filepath.Join(paths.Plugins, "testnode.toml"), []byte(pluginContent), 0644); err != nil {
+		t.Fatalf("プラグインファイル作成エラー: %v", err)
+	}
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+	manager = version.NewManager(paths, registry)
+
+	projectDir := filepath.Join(tmpDir, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("testnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	// 作業ディレクトリを変更
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	if err := runLock(); err != nil {
+		t.Fatalf("runLock() エラー: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(projectDir, config.ToolVersionLockFile))
+	if err != nil {
+		t.Fatalf("ロックファイル読み込みエラー: %v", err)
+	}
+	for _, want := range []string{`name = "testnode"`, `platform = "linux/amd64"`, "/node-v20.10.0-linux.tar.gz", "sha256 = "} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("ロックファイルに %q が含まれていません:\n%s", want, data)
+		}
+	}
+
+	// インストールは行わない
+	if _, err := os.Stat(filepath.Join(paths.Versions, "testnode")); !os.IsNotExist(err) {
+		t.Error("lock でインストールが行われました")
+	}
+}
@@ -3,7 +3,9 @@ package cli
 import (
 	"fmt"
 	"sort"
+	"strings"
 
+	"github.com/arsenal/internal/plugin"
 	"github.com/arsenal/internal/terminal"
 	"github.com/spf13/cobra"
 )
@@ -15,10 +17,20 @@ func newPluginCmd() *cobra.Command {
 		Long: `Arsenal で管理できるツール（プラグイン）の一覧を表示します。
 
 各ツールは TOML ファイルで定義されており、組み込みプラグインと
-ユーザー定義プラグイン（~/.arsenal/plugins/）が利用可能です。`,
+ユーザー定義プラグイン（~/.arsenal/plugins/）が利用可能です。
+
+plugin add を使うと、ローカルパスや git リポジトリで共有された
+プラグイン定義をまとめて追加できます。`,
 	}
 
-	cmd.AddCommand(newPluginListCmd())
+	cmd.AddCommand(
+		newPluginListCmd(),
+		newPluginAddCmd(),
+		newPluginRemoveCmd(),
+		newPluginUpdateCmd(),
+		newPluginValidateCmd(),
+		newPluginInfoCmd(),
+	)
 
 	return cmd
 }
@@ -49,6 +61,7 @@ func runPluginList() error {
 	terminal.PrintlnBlue("利用可能ツール:")
 	fmt.Println()
 
+	overrides := registry.BuiltinOverrides()
 	for _, name := range names {
 		p := plugins[name]
 		fmt.Printf("  %s\n", terminal.Green(p.DisplayName))
@@ -56,8 +69,309 @@ func runPluginList() error {
 		if p.Description != "" {
 			fmt.Printf("    説明: %s\n", p.Description)
 		}
+		if origin, ok := overrides[name]; ok {
+			fmt.Printf("    %s\n", terminal.Yellow("組み込み定義を上書き: "+origin.Path))
+		}
+		fmt.Println()
+	}
+
+	// 読み込めなかった定義を表示
+	for _, loadErr := range registry.LoadErrors() {
+		terminal.PrintWarning("読み込みエラー: %v", loadErr)
+	}
+
+	// plugin add で追加したソースを表示
+	sources, err := plugin.LoadSources(paths)
+	if err != nil {
+		return err
+	}
+	if len(sources) > 0 {
+		terminal.PrintlnBlue("プラグインソース:")
 		fmt.Println()
+		for _, name := range sortedSourceNames(sources) {
+			printSource(sources[name])
+		}
+	}
+
+	return nil
+}
+
+func newPluginAddCmd() *cobra.Command {
+	var ref string
+
+	cmd := &cobra.Command{
+		Use:   "add <name> <path-or-git-url>",
+		Short: "ローカルパスまたは git リポジトリからプラグインを追加",
+		Long: `ローカルのディレクトリ/TOML ファイル、または git リポジトリから
+プラグイン定義を取得して追加します。
+
+取得した全ての定義を検証し、問題がなければ有効化します。
+取得元とリビジョンは記録され、plugin update で更新できます。
+
+使用例:
+  arsenal plugin add mytools ./plugins
+  arsenal plugin add mytools ./terraform.toml
+  arsenal plugin add team https://github.com/example/arsenal-plugins.git
+  arsenal plugin add team git@github.com:example/arsenal-plugins.git --ref v1.2.0`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPluginAdd(args[0], args[1], ref)
+		},
+	}
+
+	cmd.Flags().StringVar(&ref, "ref", "", "git のブランチまたはタグ")
+
+	return cmd
+}
+
+func newPluginRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <name>",
+		Short: "追加したプラグインソースを削除",
+		Long: `plugin add で追加したプラグインソースと、その定義を削除します。
+
+使用例:
+  arsenal plugin remove mytools`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPluginRemove(args[0])
+		},
+	}
+}
+
+func newPluginUpdateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "update [name...]",
+		Short: "追加したプラグインソースを更新",
+		Long: `plugin add で追加したプラグインソースを取得元から更新します。
+
+名前を省略すると全てのソースを更新します。
+
+使用例:
+  arsenal plugin update
+  arsenal plugin update team`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPluginUpdate(args)
+		},
+	}
+}
+
+func runPluginAdd(name, location, ref string) error {
+	terminal.PrintfBlue("🔌 %s をプラグインソース %s として追加します\n", location, name)
+
+	src, err := plugin.AddSource(paths, name, location, ref)
+	if err != nil {
+		return err
+	}
+
+	terminal.PrintSuccess("プラグインソース %s を追加しました (%s)", src.Name, shortRevision(src.Revision))
+	fmt.Printf("  プラグイン: %s\n", strings.Join(src.Plugins, ", "))
+	return nil
+}
+
+func runPluginRemove(name string) error {
+	if err := plugin.RemoveSource(paths, name); err != nil {
+		return err
+	}
+
+	terminal.PrintSuccess("プラグインソース %s を削除しました", name)
+	return nil
+}
+
+func runPluginUpdate(names []string) error {
+	if len(names) == 0 {
+		sources, err := plugin.LoadSources(paths)
+		if err != nil {
+			return err
+		}
+		names = sortedSourceNames(sources)
+	}
+
+	if len(names) == 0 {
+		terminal.PrintlnYellow("追加されたプラグインソースがありません")
+		return nil
+	}
+
+	var failed []string
+	for _, name := range names {
+		oldRevision, src, err := plugin.UpdateSource(paths, name)
+		if err != nil {
+			terminal.PrintWarning("%s の更新に失敗: %v", name, err)
+			failed = append(failed, name)
+			continue
+		}
+
+		if oldRevision == src.Revision {
+			fmt.Printf("  %s: 変更なし (%s)\n", name, shortRevision(src.Revision))
+		} else {
+			terminal.PrintSuccess("%s を更新しました (%s → %s)", name, shortRevision(oldRevision), shortRevision(src.Revision))
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("更新に失敗したソース: %s", strings.Join(failed, ", "))
 	}
+	return nil
+}
+
+func newPluginValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate <file>",
+		Short: "プラグイン定義ファイルを検証",
+		Long: `プラグイン定義（TOML）を検証します。
+
+以下の項目を確認します:
+  - TOML の構文と未知のキー（typo）
+  - 必須フィールド（name, download_url）
+  - list_format / archive_type の値
+  - テンプレート変数と version_regex
+
+使用例:
+  arsenal plugin validate ~/.arsenal/plugins/terraform.toml`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPluginValidate(args[0])
+		},
+	}
+}
+
+func newPluginInfoCmd() *cobra.Command {
+	var ver string
+
+	cmd := &cobra.Command{
+		Use:   "info <tool>",
+		Short: "プラグインの詳細を表示",
+		Long: `プラグインの定義元、インストール済みバージョン、
+対応する全プラットフォームのダウンロード URL を表示します。
+
+--version を省略した場合は、アクティブなバージョンまたは
+最新のインストール済みバージョンで URL を解決します。
+
+使用例:
+  arsenal plugin info node
+  arsenal plugin info node --version 20.10.0`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPluginInfo(args[0], ver)
+		},
+	}
+
+	cmd.Flags().StringVar(&ver, "version", "", "URL の解決に使うバージョン")
+
+	return cmd
+}
+
+func runPluginValidate(file string) error {
+	terminal.PrintfBlue("🔍 %s を検証中...\n", file)
+	fmt.Println()
 
+	p, errs := plugin.ValidateFile(file)
+	if len(errs) > 0 {
+		terminal.PrintError("%d 件の問題が見つかりました:", len(errs))
+		for _, err := range errs {
+			fmt.Printf("  - %v\n", err)
+		}
+		return fmt.Errorf("%s の検証に失敗しました", file)
+	}
+
+	terminal.PrintSuccess("%s (%s) の定義に問題はありません", p.Name, file)
 	return nil
 }
+
+func runPluginInfo(toolName, ver string) error {
+	p, err := registry.Get(toolName)
+	if err != nil {
+		return err
+	}
+
+	terminal.PrintfBlue("%s (%s)\n", p.DisplayName, p.Name)
+	if p.Description != "" {
+		fmt.Printf("  説明: %s\n", p.Description)
+	}
+
+	// 定義元
+	if origin, ok := registry.Origin(toolName); ok {
+		fmt.Printf("  定義元: %s\n", describeOrigin(origin))
+		if origin.Overrides != nil {
+			fmt.Printf("          %s\n", terminal.Yellow("上書き: "+describeOrigin(origin.Overrides)))
+		}
+	}
+
+	// インストール済みバージョン
+	versions, err := manager.List(toolName)
+	if err != nil {
+		return fmt.Errorf("バージョン一覧取得エラー: %w", err)
+	}
+	current, _ := manager.Current(toolName)
+	if len(versions) == 0 {
+		fmt.Println("  インストール済み: なし")
+	} else {
+		labels := make([]string, 0, len(versions))
+		for _, v := range versions {
+			if v == current {
+				v = terminal.Green(v + " (現在使用中)")
+			}
+			labels = append(labels, v)
+		}
+		fmt.Printf("  インストール済み: %s\n", strings.Join(labels, ", "))
+	}
+
+	// URL 解決に使うバージョンを決定
+	if ver == "" {
+		ver = current
+	}
+	if ver == "" && len(versions) > 0 {
+		ver = versions[len(versions)-1]
+	}
+	if ver == "" {
+		ver = "<version>"
+	}
+
+	fmt.Println()
+	terminal.PrintfBlue("ダウンロード URL (%s):\n", ver)
+	for _, platform := range p.Platforms() {
+		fmt.Printf("  %-14s %s\n", platform.String(), p.ResolveDownloadURLFor(ver, platform))
+	}
+
+	return nil
+}
+
+// 読み込み元を表示用の文字列にする
+func describeOrigin(o *plugin.Origin) string {
+	switch o.Kind {
+	case plugin.OriginBuiltin:
+		return fmt.Sprintf("組み込み (%s)", o.Path)
+	case plugin.OriginSource:
+		return fmt.Sprintf("プラグインソース (%s)", o.Path)
+	default:
+		return fmt.Sprintf("ユーザー定義 (%s)", o.Path)
+	}
+}
+
+func printSource(src *plugin.Source) {
+	fmt.Printf("  %s\n", terminal.Green(src.Name))
+	fmt.Printf("    取得元: %s (%s)\n", src.Location, src.Kind)
+	if src.Ref != "" {
+		fmt.Printf("    ref: %s\n", src.Ref)
+	}
+	fmt.Printf("    リビジョン: %s\n", shortRevision(src.Revision))
+	fmt.Printf("    プラグイン: %s\n", strings.Join(src.Plugins, ", "))
+	fmt.Println()
+}
+
+func sortedSourceNames(sources map[string]*plugin.Source) []string {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// 表示用にリビジョンを短縮する
+func shortRevision(rev string) string {
+	if len(rev) > 12 {
+		return rev[:12]
+	}
+	return rev
+}
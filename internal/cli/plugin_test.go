@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/arsenal/internal/config"
 	"github.com/arsenal/internal/plugin"
+	"github.com/arsenal/internal/version"
 )
 
 // newPluginCmd が正しく作成されるかテストする
@@ -36,7 +39,7 @@ func TestNewPluginCmd(t *testing.T) {
 func TestRunPluginList(t *testing.T) {
 	// テスト用のレジストリをセットアップ
 	tmpDir := t.TempDir()
-	paths := &config.Paths{
+	paths = &config.Paths{
 		Plugins: tmpDir,
 	}
 
@@ -52,3 +55,128 @@ func TestRunPluginList(t *testing.T) {
 		t.Errorf("runPluginList() エラー: %v", err)
 	}
 }
+
+// runPluginAdd / runPluginUpdate / runPluginRemove が正しく動作するかテストする
+func TestRunPluginAddUpdateRemove(t *testing.T) {
+	tmpDir := t.TempDir()
+	paths = &config.Paths{
+		Plugins:       filepath.Join(tmpDir, "arsenal", "plugins"),
+		PluginSources: filepath.Join(tmpDir, "arsenal", "plugin-sources"),
+	}
+
+	// 共有用のプラグイン定義ディレクトリを作成
+	srcDir := filepath.Join(tmpDir, "shared")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	pluginContent := `name = "kubectl"
+display_name = "kubectl"
+download_url = "https://example.com/kubectl-{{version}}-{{os}}-{{arch}}"
+`
+	if err := os.WriteFile(filepath.Join(srcDir, "kubectl.toml"), []byte(pluginContent), 0644); err != nil {
+		t.Fatalf("プラグインファイル作成エラー: %v", err)
+	}
+
+	if err := runPluginAdd("shared", srcDir, ""); err != nil {
+		t.Fatalf("runPluginAdd() エラー: %v", err)
+	}
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+	if _, err := registry.Get("kubectl"); err != nil {
+		t.Errorf("追加したプラグインが読み込まれていません: %v", err)
+	}
+
+	if err := runPluginList(); err != nil {
+		t.Errorf("runPluginList() エラー: %v", err)
+	}
+
+	if err := runPluginUpdate(nil); err != nil {
+		t.Errorf("runPluginUpdate() エラー: %v", err)
+	}
+
+	if err := runPluginUpdate([]string{"unknown"}); err == nil {
+		t.Error("存在しないソースの更新でエラーが返されませんでした")
+	}
+
+	if err := runPluginRemove("shared"); err != nil {
+		t.Fatalf("runPluginRemove() エラー: %v", err)
+	}
+
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+	if _, err := registry.Get("kubectl"); err == nil {
+		t.Error("削除したプラグインがまだ読み込まれています")
+	}
+}
+
+// runPluginValidate が正しい定義と不正な定義を判定するかテストする
+func TestRunPluginValidate(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	valid := filepath.Join(tmpDir, "valid.toml")
+	validContent := `name = "terraform"
+display_name = "Terraform"
+download_url = "https://example.com/terraform_{{version}}_{{os}}_{{arch}}.zip"
+archive_type = "zip"
+`
+	if err := os.WriteFile(valid, []byte(validContent), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+	if err := runPluginValidate(valid); err != nil {
+		t.Errorf("runPluginValidate() エラー: %v", err)
+	}
+
+	invalid := filepath.Join(tmpDir, "invalid.toml")
+	invalidContent := `name = "terraform"
+download_url = "https://example.com/terraform.zip"
+archive_type = "rar"
+`
+	if err := os.WriteFile(invalid, []byte(invalidContent), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+	if err := runPluginValidate(invalid); err == nil {
+		t.Error("不正な定義でエラーが返されませんでした")
+	}
+}
+
+// runPluginInfo が正しく動作するかテストする
+func TestRunPluginInfo(t *testing.T) {
+	tmpDir := t.TempDir()
+	paths = &config.Paths{
+		Root:     filepath.Join(tmpDir, "arsenal"),
+		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
+		Current:  filepath.Join(tmpDir, "arsenal", "current"),
+		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+	}
+
+	if err := paths.EnsureDirs(); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	// インストール済みバージョンを作成
+	if err := os.MkdirAll(filepath.Join(paths.Versions, "node", "20.10.0"), 0755); err != nil {
+		t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+	}
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+
+	manager = version.NewManager(paths, registry)
+
+	if err := runPluginInfo("node", ""); err != nil {
+		t.Errorf("runPluginInfo() エラー: %v", err)
+	}
+
+	if err := runPluginInfo("nonexistent", ""); err == nil {
+		t.Error("存在しないプラグインでエラーが返されませんでした")
+	}
+}
@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/arsenal/internal/version"
+)
+
+// シェル設定ファイルに書き込むブロックの開始・終了マーカー（対応シェルは全て # でコメントになる）
+const (
+	rcBlockBegin = "# >>> bastion-arsenal init-shell >>>"
+	rcBlockEnd   = "# <<< bastion-arsenal init-shell <<<"
+)
+
+// ブロックの状態を表す
+type rcBlockState int
+
+const (
+	rcBlockMissing rcBlockState = iota
+	rcBlockManual               // ブロックはないが eval "$(bastion-arsenal init-shell ...)" などで設定済み
+	rcBlockOutdated
+	rcBlockUpToDate
+)
+
+// シェルごとの設定ファイルのパスを返す
+func shellRCFile(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("ホームディレクトリ取得エラー: %w", err)
+	}
+
+	switch shell {
+	case "bash":
+		// macOS のログインシェルは .bashrc を読まないため、.bash_profile しかない場合はそちらを使う
+		rc := filepath.Join(home, ".bashrc")
+		if runtime.GOOS == "darwin" && !fileExists(rc) {
+			if profile := filepath.Join(home, ".bash_profile"); fileExists(profile) {
+				return profile, nil
+			}
+		}
+		return rc, nil
+
+	case "zsh":
+		if zdot := os.Getenv("ZDOTDIR"); zdot != "" {
+			return filepath.Join(zdot, ".zshrc"), nil
+		}
+		return filepath.Join(home, ".zshrc"), nil
+
+	case "fish":
+		return filepath.Join(xdgConfigHome(home), "fish", "config.fish"), nil
+
+	case "powershell":
+		// $PROFILE (CurrentUserCurrentHost) の既定の場所
+		if runtime.GOOS == "windows" {
+			return filepath.Join(home, "Documents", "PowerShell", "Microsoft.PowerShell_profile.ps1"), nil
+		}
+		return filepath.Join(xdgConfigHome(home), "powershell", "Microsoft.PowerShell_profile.ps1"), nil
+
+	case "nu":
+		// nushell は OS 標準の設定ディレクトリを使う ($nu.config-path)
+		dir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("設定ディレクトリ取得エラー: %w", err)
+		}
+		return filepath.Join(dir, "nushell", "config.nu"), nil
+	}
+
+	return "", fmt.Errorf("サポートされていないシェル: %s (%s のみ対応)", shell, strings.Join(initShells, ", "))
+}
+
+func xdgConfigHome(home string) string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return dir
+	}
+	return filepath.Join(home, ".config")
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// マーカーで囲んだ初期化スクリプトのブロックを返す
+func rcBlock(shell string) (string, error) {
+	script, err := initScript(shell)
+	if err != nil {
+		return "", err
+	}
+	return rcBlockBegin + "\n" + script + rcBlockEnd + "\n", nil
+}
+
+// content 内のブロックの範囲をバイト位置で返す（終了マーカーの改行まで含む）
+func findRCBlock(content string) (start, end int, found bool, err error) {
+	start = -1
+	offset := 0
+	for offset < len(content) {
+		lineEnd := strings.IndexByte(content[offset:], '\n')
+		next := len(content)
+		if lineEnd >= 0 {
+			next = offset + lineEnd + 1
+		}
+		line := strings.TrimRight(content[offset:next], "\r\n")
+
+		switch {
+		case line == rcBlockBegin && start < 0:
+			start = offset
+		case line == rcBlockEnd && start >= 0:
+			return start, next, true, nil
+		}
+		offset = next
+	}
+
+	if start >= 0 {
+		return 0, 0, false, fmt.Errorf("終了マーカー %q が見つかりません", rcBlockEnd)
+	}
+	return 0, 0, false, nil
+}
+
+// 設定ファイルにブロックを書き込む
+// 既存のブロックがあればその場で置き換え、なければ末尾に追加する
+// 内容に変更がなければ書き込まず false を返す
+func installRCBlock(path, block string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return false, fmt.Errorf("設定ファイル読み込みエラー: %w", err)
+	}
+	content := string(data)
+
+	start, end, found, err := findRCBlock(content)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var updated string
+	if found {
+		updated = content[:start] + block + content[end:]
+	} else {
+		updated = content
+		if updated != "" {
+			if !strings.HasSuffix(updated, "\n") {
+				updated += "\n"
+			}
+			updated += "\n"
+		}
+		updated += block
+	}
+
+	if updated == content {
+		return false, nil
+	}
+
+	if err := writeRCFile(path, data, updated); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// 設定ファイルからブロックを取り除く
+// ブロックがなければ何もせず false を返す
+func uninstallRCBlock(path string) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("設定ファイル読み込みエラー: %w", err)
+	}
+	content := string(data)
+
+	start, end, found, err := findRCBlock(content)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", path, err)
+	}
+	if !found {
+		return false, nil
+	}
+
+	// 追加時に入れた空行も取り除く
+	before := content[:start]
+	if strings.HasSuffix(before, "\n\n") {
+		before = before[:len(before)-1]
+	}
+
+	if err := writeRCFile(path, data, before+content[end:]); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// 既存の内容を <path>.arsenal.bak に退避してから設定ファイルを書き換える
+func writeRCFile(path string, original []byte, content string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+		if err := os.WriteFile(path+".arsenal.bak", original, mode); err != nil {
+			return fmt.Errorf("バックアップ作成エラー: %w", err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("ディレクトリ作成エラー: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), mode); err != nil {
+		return fmt.Errorf("設定ファイル書き込みエラー: %w", err)
+	}
+	return nil
+}
+
+// 設定ファイル内のブロックが現在の init-shell の出力と一致するか調べる
+func rcBlockStatus(path, block string) (rcBlockState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return rcBlockMissing, nil
+		}
+		return rcBlockMissing, err
+	}
+	content := strings.ReplaceAll(string(data), "\r\n", "\n")
+
+	start, end, found, err := findRCBlock(content)
+	if err != nil {
+		return rcBlockMissing, err
+	}
+	if !found {
+		if strings.Contains(content, "bastion-arsenal init-shell") {
+			return rcBlockManual, nil
+		}
+		return rcBlockMissing, nil
+	}
+	if content[start:end] != block {
+		return rcBlockOutdated, nil
+	}
+	return rcBlockUpToDate, nil
+}
+
+// doctor 用にシェル設定ファイルのブロックを診断する
+// ログインシェル ($SHELL) は常に、それ以外はブロックが書き込まれているシェルだけを報告する
+func checkShellRCFiles() []version.DiagResult {
+	loginShell := strings.TrimSuffix(filepath.Base(os.Getenv("SHELL")), ".exe")
+	if loginShell == "pwsh" {
+		loginShell = "powershell"
+	}
+
+	var results []version.DiagResult
+	for _, shell := range initShells {
+		path, err := shellRCFile(shell)
+		if err != nil {
+			continue
+		}
+		block, err := rcBlock(shell)
+		if err != nil {
+			continue
+		}
+
+		name := fmt.Sprintf("シェル設定 (%s)", shell)
+		state, err := rcBlockStatus(path, block)
+		if err != nil {
+			results = append(results, version.DiagResult{Name: name, Status: version.StatusError, Message: err.Error()})
+			continue
+		}
+
+		switch state {
+		case rcBlockUpToDate:
+			results = append(results, version.DiagResult{Name: name, Status: version.StatusOK, Message: path})
+		case rcBlockManual:
+			results = append(results, version.DiagResult{Name: name, Status: version.StatusOK, Message: path + " (ブロック外で設定)"})
+		case rcBlockOutdated:
+			results = append(results, version.DiagResult{
+				Name:    name,
+				Status:  version.StatusWarn,
+				Message: fmt.Sprintf("%s のブロックが古くなっています (bastion-arsenal init-shell %s --install で更新)", path, shell),
+			})
+		case rcBlockMissing:
+			if shell == loginShell {
+				results = append(results, version.DiagResult{
+					Name:    name,
+					Status:  version.StatusWarn,
+					Message: fmt.Sprintf("%s にブロックがありません (bastion-arsenal init-shell %s --install で追加)", path, shell),
+				})
+			}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/version"
+)
+
+// init-shell のブロックを生成できるようにパスを設定する
+func setupRCFileTest(t *testing.T) string {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	paths = &config.Paths{
+		Root:  filepath.Join(tmpDir, "arsenal"),
+		Bin:   filepath.Join(tmpDir, "arsenal", "bin"),
+		Shims: filepath.Join(tmpDir, "arsenal", "shims"),
+	}
+	cfg = nil
+
+	home := filepath.Join(tmpDir, "home")
+	if err := os.MkdirAll(home, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("ZDOTDIR", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SHELL", "/bin/bash")
+
+	return home
+}
+
+// ブロックの追加・置き換え・削除が既存の内容を壊さないかテストする
+func TestInstallRCBlock(t *testing.T) {
+	home := setupRCFileTest(t)
+	rc := filepath.Join(home, ".bashrc")
+
+	original := "alias ll='ls -l'\nexport EDITOR=vim"
+	if err := os.WriteFile(rc, []byte(original), 0600); err != nil {
+		t.Fatalf("設定ファイル作成エラー: %v", err)
+	}
+
+	block, err := rcBlock("bash")
+	if err != nil {
+		t.Fatalf("rcBlock() エラー: %v", err)
+	}
+
+	changed, err := installRCBlock(rc, block)
+	if err != nil {
+		t.Fatalf("installRCBlock() エラー: %v", err)
+	}
+	if !changed {
+		t.Error("初回の書き込みで changed = false")
+	}
+
+	data, _ := os.ReadFile(rc)
+	want := original + "\n\n" + block
+	if string(data) != want {
+		t.Errorf("設定ファイル = %q, want %q", data, want)
+	}
+
+	// 書き換え前の内容がバックアップされ、パーミッションが保たれる
+	backup, err := os.ReadFile(rc + ".arsenal.bak")
+	if err != nil {
+		t.Fatalf("バックアップ読み込みエラー: %v", err)
+	}
+	if string(backup) != original {
+		t.Errorf("バックアップ = %q, want %q", backup, original)
+	}
+	if info, _ := os.Stat(rc); info.Mode().Perm() != 0600 {
+		t.Errorf("パーミッション = %v, want 0600", info.Mode().Perm())
+	}
+
+	// 再実行しても重複しない
+	changed, err = installRCBlock(rc, block)
+	if err != nil {
+		t.Fatalf("installRCBlock() エラー: %v", err)
+	}
+	if changed {
+		t.Error("同じ内容で changed = true")
+	}
+
+	// 古いブロックはその場で置き換わり、後ろの行は残る
+	stale := strings.Replace(want, block, rcBlockBegin+"\nold script\n"+rcBlockEnd+"\n", 1) + "export FOO=1\n"
+	if err := os.WriteFile(rc, []byte(stale), 0600); err != nil {
+		t.Fatalf("設定ファイル作成エラー: %v", err)
+	}
+	if _, err := installRCBlock(rc, block); err != nil {
+		t.Fatalf("installRCBlock() エラー: %v", err)
+	}
+	data, _ = os.ReadFile(rc)
+	if string(data) != want+"export FOO=1\n" {
+		t.Errorf("置き換え後の設定ファイル = %q", data)
+	}
+
+	// 削除すると追加前の内容に戻る
+	removed, err := uninstallRCBlock(rc)
+	if err != nil {
+		t.Fatalf("uninstallRCBlock() エラー: %v", err)
+	}
+	if !removed {
+		t.Error("uninstallRCBlock() = false, want true")
+	}
+	data, _ = os.ReadFile(rc)
+	if string(data) != original+"\nexport FOO=1\n" {
+		t.Errorf("削除後の設定ファイル = %q", data)
+	}
+
+	removed, err = uninstallRCBlock(rc)
+	if err != nil {
+		t.Fatalf("uninstallRCBlock() エラー: %v", err)
+	}
+	if removed {
+		t.Error("ブロックがないのに uninstallRCBlock() = true")
+	}
+}
+
+// 存在しない設定ファイルはディレクトリごと作成されるかテストする
+func TestInstallRCBlockNewFile(t *testing.T) {
+	home := setupRCFileTest(t)
+	rc := filepath.Join(home, ".config", "fish", "config.fish")
+
+	block, err := rcBlock("fish")
+	if err != nil {
+		t.Fatalf("rcBlock() エラー: %v", err)
+	}
+	if _, err := installRCBlock(rc, block); err != nil {
+		t.Fatalf("installRCBlock() エラー: %v", err)
+	}
+
+	data, _ := os.ReadFile(rc)
+	if string(data) != block {
+		t.Errorf("設定ファイル = %q, want %q", data, block)
+	}
+	if _, err := os.Stat(rc + ".arsenal.bak"); !os.IsNotExist(err) {
+		t.Error("新規作成でバックアップが作られました")
+	}
+}
+
+// 終了マーカーのないブロックはエラーになるかテストする
+func TestFindRCBlockMissingEnd(t *testing.T) {
+	_, _, _, err := findRCBlock("export A=1\n" + rcBlockBegin + "\nscript\n")
+	if err == nil {
+		t.Error("終了マーカーがないのにエラーが返されませんでした")
+	}
+
+	start, end, found, err := findRCBlock("a\r\n" + rcBlockBegin + "\r\nscript\r\n" + rcBlockEnd + "\r\nb\r\n")
+	if err != nil || !found {
+		t.Fatalf("CRLF のブロックが見つかりません: %v", err)
+	}
+	if start != 3 || end != 3+len(rcBlockBegin+"\r\nscript\r\n"+rcBlockEnd+"\r\n") {
+		t.Errorf("findRCBlock() = (%d, %d)", start, end)
+	}
+}
+
+// シェルごとの設定ファイルの場所をテストする
+func TestShellRCFile(t *testing.T) {
+	home := setupRCFileTest(t)
+
+	tests := []struct {
+		shell string
+		env   map[string]string
+		want  string
+	}{
+		{"bash", nil, filepath.Join(home, ".bashrc")},
+		{"zsh", nil, filepath.Join(home, ".zshrc")},
+		{"zsh", map[string]string{"ZDOTDIR": "/tmp/zdot"}, filepath.Join("/tmp/zdot", ".zshrc")},
+		{"fish", nil, filepath.Join(home, ".config", "fish", "config.fish")},
+		{"fish", map[string]string{"XDG_CONFIG_HOME": "/tmp/xdg"}, filepath.Join("/tmp/xdg", "fish", "config.fish")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			got, err := shellRCFile(tt.shell)
+			if err != nil {
+				t.Fatalf("shellRCFile(%q) エラー: %v", tt.shell, err)
+			}
+			if got != tt.want {
+				t.Errorf("shellRCFile(%q) = %q, want %q", tt.shell, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := shellRCFile("tcsh"); err == nil {
+		t.Error("未対応のシェルでエラーが返されませんでした")
+	}
+}
+
+// doctor がブロックの有無と鮮度を報告するかテストする
+func TestCheckShellRCFiles(t *testing.T) {
+	home := setupRCFileTest(t)
+	bashrc := filepath.Join(home, ".bashrc")
+	zshrc := filepath.Join(home, ".zshrc")
+
+	statusOf := func(name string) (version.DiagStatus, bool) {
+		for _, r := range checkShellRCFiles() {
+			if r.Name == name {
+				return r.Status, true
+			}
+		}
+		return 0, false
+	}
+
+	// ログインシェルにブロックがなければ警告、それ以外のシェルは報告しない
+	if status, ok := statusOf("シェル設定 (bash)"); !ok || status != version.StatusWarn {
+		t.Errorf("ブロックなしの bash: status = %v, reported = %v", status, ok)
+	}
+	if _, ok := statusOf("シェル設定 (zsh)"); ok {
+		t.Error("ブロックのない zsh が報告されました")
+	}
+
+	if err := runInitShellInstall("bash", ""); err != nil {
+		t.Fatalf("runInitShellInstall() エラー: %v", err)
+	}
+	if status, _ := statusOf("シェル設定 (bash)"); status != version.StatusOK {
+		t.Errorf("最新のブロック: status = %v, want OK", status)
+	}
+
+	// 設定が変わるとブロックが古いと報告される
+	cfg = &config.Config{Mode: config.ModeShims}
+	defer func() { cfg = nil }()
+	if status, _ := statusOf("シェル設定 (bash)"); status != version.StatusWarn {
+		t.Errorf("古いブロック: status = %v, want Warn", status)
+	}
+	if err := runInitShellInstall("bash", bashrc); err != nil {
+		t.Fatalf("runInitShellInstall() エラー: %v", err)
+	}
+	if status, _ := statusOf("シェル設定 (bash)"); status != version.StatusOK {
+		t.Errorf("更新後: status = %v, want OK", status)
+	}
+
+	// eval で読み込んでいる場合はブロックがなくても OK
+	if err := os.WriteFile(zshrc, []byte(`eval "$(bastion-arsenal init-shell zsh)"`+"\n"), 0644); err != nil {
+		t.Fatalf("設定ファイル作成エラー: %v", err)
+	}
+	if status, ok := statusOf("シェル設定 (zsh)"); !ok || status != version.StatusOK {
+		t.Errorf("eval で設定済みの zsh: status = %v, reported = %v", status, ok)
+	}
+}
@@ -6,12 +6,15 @@ import (
 
 	"github.com/arsenal/internal/config"
 	"github.com/arsenal/internal/plugin"
+	"github.com/arsenal/internal/terminal"
 	"github.com/arsenal/internal/version"
 	"github.com/spf13/cobra"
 )
 
 var (
 	paths    *config.Paths
+	cfg      *config.Config
+	cfgErr   error // 設定ファイルの読み込みエラー（cfg はデフォルト値になる）
 	registry *plugin.Registry
 	manager  *version.Manager
 
@@ -115,10 +118,19 @@ func NewRootCmd() *cobra.Command {
 		newListCmd(),
 		newLsRemoteCmd(),
 		newCurrentCmd(),
+		newWhichCmd(),
+		newInitCmd(),
 		newSyncCmd(),
+		newLockCmd(),
 		newDoctorCmd(),
 		newPluginCmd(),
 		newInitShellCmd(),
+		newEnvCmd(),
+		newExecCmd(),
+		newShellCmd(),
+		newHookEnvCmd(),
+		newReshimCmd(),
+		newShimExecCmd(),
 		newVersionCmd(),
 		newSelfCmd(),
 	)
@@ -139,11 +151,27 @@ func initialize() error {
 		return fmt.Errorf("ディレクトリ作成エラー: %w", err)
 	}
 
+	// 設定ファイルが壊れていてもシェルのフックなどが動くよう、デフォルト値で続行する
+	cfg, cfgErr = config.LoadConfig(paths.Config)
+	if cfgErr != nil {
+		fmt.Fprintln(os.Stderr, terminal.Yellow("⚠️  設定を読み込めませんでした (デフォルトの symlink モードで続行): "+cfgErr.Error()))
+		fmt.Fprintln(os.Stderr, terminal.Yellow("   詳細は 'arsenal doctor' で確認してください"))
+	}
+
 	registry, err = plugin.NewRegistry(paths)
 	if err != nil {
 		return fmt.Errorf("プラグイン読み込みエラー: %w", err)
 	}
 
+	// 壊れたユーザー定義があっても他のコマンドは実行できるよう、警告のみ表示する
+	// 標準出力は env などで eval されるため標準エラー出力に書き出す
+	for _, loadErr := range registry.LoadErrors() {
+		fmt.Fprintln(os.Stderr, terminal.Yellow("⚠️  プラグイン定義を読み込めませんでした: "+loadErr.Error()))
+	}
+	if len(registry.LoadErrors()) > 0 {
+		fmt.Fprintln(os.Stderr, terminal.Yellow("   詳細は 'arsenal doctor' で確認してください"))
+	}
+
 	manager = version.NewManager(paths, registry)
 	_ = manager // 将来の CLI コマンドで使用予定
 	return nil
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func newShellCmd() *cobra.Command {
+	var unset bool
+	var emit string
+
+	cmd := &cobra.Command{
+		Use:   "shell <tool> <version>",
+		Short: "現在のシェルだけでツールのバージョンを切り替え",
+		Long: `ARSENAL_<TOOL>_VERSION 環境変数を設定して、現在のシェルだけで
+ツールのバージョンを切り替えます。グローバルの symlink や他のターミナルには
+影響せず、.toolversions の指定よりも優先されます。
+
+子プロセスは親シェルの環境変数を変更できないため、init-shell が生成する
+ラッパー関数を通して実行する必要があります。
+
+使用例:
+  arsenal shell node 18.19.0
+  arsenal shell node 18          # インストール済みの 18.x の最新
+  arsenal shell --unset node     # 上書きを解除`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if unset {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(2)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ver := ""
+			if len(args) > 1 {
+				ver = args[1]
+			}
+			return runShell(args[0], ver, unset, emit)
+		},
+	}
+
+	// ラッパー関数は標準出力を評価するため、ヘルプや使い方は標準エラー出力に書き出す
+	cmd.SetOut(os.Stderr)
+
+	cmd.Flags().BoolVar(&unset, "unset", false, "シェルでの上書きを解除")
+	// init-shell のラッパー関数が評価用のコードを受け取るために指定する
+	cmd.Flags().StringVar(&emit, "emit", "", "評価するシェルコードの形式 (sh, fish, powershell, nu)")
+	_ = cmd.Flags().MarkHidden("emit")
+
+	return cmd
+}
+
+func runShell(tool, spec string, unset bool, emit string) error {
+	format := emit
+	if format == "" {
+		format = "sh"
+	}
+	if !slices.Contains(shellCodeFormats, format) {
+		return fmt.Errorf("サポートされていない形式: %s (%s のみ対応)", emit, strings.Join(shellCodeFormats, ", "))
+	}
+
+	p, err := registry.Get(tool)
+	if err != nil {
+		return err
+	}
+
+	name := version.EnvOverrideVar(tool)
+
+	var change envChange
+	var message string
+	if unset {
+		change = envChange{Name: name, Unset: true}
+		message = fmt.Sprintf("%s のシェルでの上書きを解除しました", p.DisplayName)
+	} else {
+		ver, err := manager.FindInstalled(tool, spec)
+		if err != nil {
+			return err
+		}
+		if ver == "" {
+			return fmt.Errorf("%s %s に一致するバージョンがインストールされていません ('arsenal install %s <version>' を実行)", tool, spec, tool)
+		}
+		change = envChange{Name: name, Value: ver}
+		message = fmt.Sprintf("このシェルで %s %s を使用します", p.DisplayName, ver)
+	}
+
+	line := renderEnvChanges(format, []envChange{change})[0]
+
+	// ラッパー関数を通さずに実行された場合は、評価すべきコードを案内する
+	if emit == "" {
+		terminal.PrintWarning("arsenal shell は init-shell のラッパー関数を通して実行してください")
+		fmt.Println("   現在のシェルに反映するには次を実行:")
+		fmt.Printf("   %s\n", line)
+		return fmt.Errorf("シェル統合が設定されていません ('arsenal init-shell' 参照)")
+	}
+
+	// 標準出力はラッパー関数が評価するため、メッセージは標準エラー出力に書き出す
+	fmt.Println(line)
+	fmt.Fprintln(os.Stderr, terminal.Green("✅ "+message))
+	return nil
+}
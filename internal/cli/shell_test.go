@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newShellCmd が正しく作成されるかテストする
+func TestNewShellCmd(t *testing.T) {
+	cmd := newShellCmd()
+
+	if cmd.Use != "shell <tool> <version>" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "shell <tool> <version>")
+	}
+	if cmd.Flags().Lookup("emit") == nil || !cmd.Flags().Lookup("emit").Hidden {
+		t.Error("--emit は非表示のフラグです")
+	}
+}
+
+// runShell がラッパー関数用のシェルコードを出力するかテストする
+func TestRunShell(t *testing.T) {
+	setupExecTest(t, "18.19.0", "18.20.1")
+
+	tests := []struct {
+		name  string
+		spec  string
+		unset bool
+		emit  string
+		want  string
+	}{
+		{name: "sh", spec: "18", emit: "sh", want: "export ARSENAL_NODE_VERSION='18.20.1'"},
+		{name: "fish", spec: "18.19.0", emit: "fish", want: "set -gx ARSENAL_NODE_VERSION '18.19.0'"},
+		{name: "unset", unset: true, emit: "sh", want: "unset ARSENAL_NODE_VERSION"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 標準出力をキャプチャ
+			oldStdout := os.Stdout
+			r, w, _ := os.Pipe()
+			os.Stdout = w
+
+			err := runShell("node", tt.spec, tt.unset, tt.emit)
+
+			// 標準出力を復元
+			_ = w.Close()
+			os.Stdout = oldStdout
+
+			var buf bytes.Buffer
+			_, _ = io.Copy(&buf, r)
+
+			if err != nil {
+				t.Fatalf("runShell() エラー: %v", err)
+			}
+			if strings.TrimSpace(buf.String()) != tt.want {
+				t.Errorf("出力 = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// runShell がエラーを返すケースをテストする
+func TestRunShellErrors(t *testing.T) {
+	setupExecTest(t, "18.19.0")
+
+	// 標準出力を捨てる
+	oldStdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = oldStdout }()
+
+	if err := runShell("node", "20", false, "sh"); err == nil {
+		t.Error("未インストールのバージョンでエラーが返されませんでした")
+	}
+	if err := runShell("unknown", "1.0", false, "sh"); err == nil {
+		t.Error("不明なツールでエラーが返されませんでした")
+	}
+	if err := runShell("node", "18", false, "tcsh"); err == nil {
+		t.Error("不明な形式でエラーが返されませんでした")
+	}
+	// ラッパー関数を通さない実行はエラー
+	if err := runShell("node", "18", false, ""); err == nil {
+		t.Error("--emit なしの実行でエラーが返されませんでした")
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func newReshimCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "reshim",
+		Short: "shims モード用のシムを再生成",
+		Long: `インストール済みの全バージョンが公開する実行ファイルについて、
+~/.arsenal/shims にシムを生成し直します。
+
+シムは実行されるたびにカレントディレクトリからバージョンを解決するため、
+シェルフックを使わないエディタや cron からも正しいバージョンが使われます。
+install / uninstall はシムを自動で更新するため、手動で追加した
+バージョンやプラグインの変更を反映するときに実行してください。
+
+使用例:
+  bastion-arsenal reshim`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runReshim()
+		},
+	}
+}
+
+func runReshim() error {
+	names, err := manager.Reshim()
+	if err != nil {
+		return fmt.Errorf("シム生成エラー: %w", err)
+	}
+
+	terminal.PrintSuccess("%d 個のシムを %s に生成しました", len(names), paths.Shims)
+
+	if !shimsMode() {
+		terminal.PrintInfo("シムを使うには %s に mode = \"%s\" を設定し、init-shell の出力を再設定してください",
+			paths.Config, config.ModeShims)
+	}
+	return nil
+}
+
+func newShimExecCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                "shim-exec <tool> <executable> [args...]",
+		Short:              "シムから呼び出され、解決したバージョンの実行ファイルを起動",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		Args:               cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runShimExec(args[0], args[1], args[2:])
+		},
+	}
+}
+
+func runShimExec(tool, name string, args []string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("カレントディレクトリ取得エラー: %w", err)
+	}
+
+	// .toolversions が壊れていても環境変数やグローバルの設定で続行する
+	r, err := manager.ResolveTool(dir, tool)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "arsenal: %v\n", err)
+	}
+
+	if r.Version == "" {
+		return fmt.Errorf("%s のバージョンが決まっていません (.toolversions に追加するか 'arsenal use %s <version>' を実行)", tool, tool)
+	}
+	if !r.Installed {
+		return fmt.Errorf("%s %s はインストールされていません ('arsenal install %s %s' を実行)", tool, r.Version, tool, r.Version)
+	}
+
+	// 複数のバージョンが指定されている場合、先のバージョンにない実行ファイルは後ろのバージョンから使う
+	path, _, err := manager.FindResolvedExecutable(r, name)
+	if err != nil {
+		return err
+	}
+
+	env := manager.ProcessEnv(os.Environ(), []version.Resolution{r})
+	return execProcess(path, append([]string{name}, args...), env)
+}
+
+// 設定ファイルで shims モードが選択されているか判定する
+func shimsMode() bool {
+	return cfg != nil && cfg.Mode == config.ModeShims
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/plugin"
+	"github.com/arsenal/internal/version"
+)
+
+// shims モードのテスト環境をセットアップするヘルパー関数
+func setupShimsTest(t *testing.T) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	paths = &config.Paths{
+		Root:     filepath.Join(tmpDir, "arsenal"),
+		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
+		Current:  filepath.Join(tmpDir, "arsenal", "current"),
+		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+		Config:   filepath.Join(tmpDir, "arsenal", config.ConfigFile),
+		Bin:      filepath.Join(tmpDir, "arsenal", "bin"),
+		Shims:    filepath.Join(tmpDir, "arsenal", "shims"),
+	}
+	if err := paths.EnsureDirs(); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	cfg = &config.Config{Mode: config.ModeShims}
+	t.Cleanup(func() { cfg = nil })
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+
+	manager = version.NewManager(paths, registry)
+}
+
+// newReshimCmd が正しく作成されるかテストする
+func TestNewReshimCmd(t *testing.T) {
+	cmd := newReshimCmd()
+
+	if cmd.Use != "reshim" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "reshim")
+	}
+}
+
+// runReshim が node のシムを生成するかテストする
+func TestRunReshim(t *testing.T) {
+	setupShimsTest(t)
+
+	nodeBin := filepath.Join(paths.Versions, "node", "20.10.0", "bin")
+	if err := os.MkdirAll(nodeBin, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(nodeBin, "node"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("実行ファイル作成エラー: %v", err)
+	}
+
+	// 標準出力をキャプチャ
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runReshim()
+
+	// 標準出力を復元
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+
+	if err != nil {
+		t.Fatalf("runReshim() エラー: %v", err)
+	}
+	if !strings.Contains(buf.String(), "1 個のシム") {
+		t.Errorf("生成数が表示されていません: %s", buf.String())
+	}
+	if _, err := os.Stat(filepath.Join(paths.Shims, "node")); err != nil {
+		t.Errorf("node のシムが生成されていません: %v", err)
+	}
+}
+
+// runShimExec がバージョン未設定・未インストールでエラーを返すかテストする
+func TestRunShimExecErrors(t *testing.T) {
+	setupShimsTest(t)
+
+	// .toolversions のないディレクトリに移動
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(t.TempDir())
+
+	err := runShimExec("node", "node", nil)
+	if err == nil || !strings.Contains(err.Error(), "バージョンが決まっていません") {
+		t.Errorf("runShimExec() = %v, want バージョン未設定エラー", err)
+	}
+
+	t.Setenv("ARSENAL_NODE_VERSION", "18.19.0")
+	err = runShimExec("node", "node", nil)
+	if err == nil || !strings.Contains(err.Error(), "インストールされていません") {
+		t.Errorf("runShimExec() = %v, want 未インストールエラー", err)
+	}
+}
+
+// shims モードでは init-shell がシムディレクトリを PATH に追加するかテストする
+func TestRunInitShellShims(t *testing.T) {
+	setupShimsTest(t)
+
+	// 標準出力をキャプチャ
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runInitShell("zsh")
+
+	// 標準出力を復元
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("runInitShell() エラー: %v", err)
+	}
+	if !strings.Contains(output, `export PATH="`+paths.Shims+`:$PATH"`) {
+		t.Error("シムディレクトリが PATH に含まれていません")
+	}
+	// プラグインの env_vars を反映するためにフックは入る
+	if !strings.Contains(output, "hook-env --shell zsh") {
+		t.Error("shims モードでシェルフックが含まれていません")
+	}
+}
+
+// shims モードのフックが PATH を変えないかテストする
+func TestHookBinDirsShims(t *testing.T) {
+	setupShimsTest(t)
+
+	if err := os.MkdirAll(filepath.Join(paths.Versions, "node", "20.10.0", "bin"), 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	pinned := []version.Resolution{{Tool: "node", Version: "20.10.0", Source: version.SourceToolVersions, Installed: true}}
+
+	if dirs := hookBinDirs(pinned); len(dirs) != 0 {
+		t.Errorf("hookBinDirs() = %v, want empty", dirs)
+	}
+
+	cfg = &config.Config{Mode: config.ModeSymlink}
+	if dirs := hookBinDirs(pinned); len(dirs) != 1 {
+		t.Errorf("hookBinDirs() = %v, want 1 dir", dirs)
+	}
+}
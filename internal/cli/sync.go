@@ -1,36 +1,171 @@
 package cli
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"strings"
 
+	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
 	"github.com/spf13/cobra"
 )
 
 func newSyncCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "sync",
+	var explain, recursive bool
+	var opts version.SyncOptions
+
+	cmd := &cobra.Command{
+		Use:   "sync [dir]",
 		Short: ".toolversions からバージョンを同期",
 		Long: `.toolversions ファイルに記載された全ツールのバージョンを
 インストールして切り替えます。
 
-.toolversions ファイルは現在のディレクトリから上位ディレクトリへと
-遡って検索されます。
+.toolversions ファイルは現在のディレクトリからルートまで上位ディレクトリを
+遡って検索され、見つかった全てのファイルが統合されます。
+同じツールが複数のファイルにある場合は、最も近いファイルの指定が使われます。
+
+.toolversions の [ci] のような見出しより後の行はプロファイルの指定です。
+--profile（または環境変数 ARSENAL_PROFILE）で選んだプロファイルの指定が
+ベースの指定に追加され、同じツールはプロファイルの指定で上書きされます。
+
+解決したバージョン、ダウンロード URL、アーカイブの SHA-256 は
+.toolversions.lock に記録され、次回以降の同期ではロックされたバージョンを
+インストールします。--frozen を指定すると、ロックファイルの内容だけで
+インストールし、.toolversions との食い違いやチェックサムの不一致があれば失敗します。
+
+--explain を指定すると、インストールせずに各ツールの指定元のファイルと
+上書きされた指定を表示します。
+
+--dry-run を指定すると、インストールと切り替えの計画を表示するだけで
+何も変更しません。--check は環境が .toolversions と一致しなければ
+0 以外の終了コードで終了します（CI での確認用、何もインストールしません）。
+
+--global を指定すると、.toolversions の代わりにグローバルのデフォルト
+（~/.arsenal/toolversions、arsenal use --global で記録）を同期します。
+新しいマシンでの初期セットアップに使います（ロックファイルは使いません）。
+
+--recursive を指定すると、dir（省略時は現在のディレクトリ）以下の全ての
+.toolversions / .tool-versions / legacy_files（リポジトリの .gitignore で
+無視されるものを除く）が必要とするバージョンを
+まとめて、それぞれ 1 回だけ並列にインストールします。サブプロジェクトごとに
+必要なバージョンを表示します。グローバルのバージョンとロックファイルは変更しません。
+
+インストールや切り替えに失敗したツールがあると、最後にまとめて表示して
+0 以外の終了コードで終了します。
 
 使用例:
-  arsenal sync`,
+  arsenal sync
+  arsenal sync --frozen
+  arsenal sync --profile ci
+  arsenal sync --dry-run
+  arsenal sync --check
+  arsenal sync --global
+  arsenal sync --recursive services
+  arsenal sync --explain`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runSync()
+			if recursive {
+				dir := "."
+				if len(args) > 0 {
+					dir = args[0]
+				}
+				return manager.SyncRecursive(dir, opts)
+			}
+			if len(args) > 0 {
+				return fmt.Errorf("ディレクトリの指定は --recursive と一緒に使います")
+			}
+			return runSync(explain, opts)
 		},
 	}
+
+	cmd.Flags().BoolVar(&explain, "explain", false, "各ツールの指定元のファイルを表示（インストールしない）")
+	cmd.Flags().BoolVar(&opts.Frozen, "frozen", false, "ロックファイルの通りにインストール（食い違いがあれば失敗）")
+	cmd.Flags().StringSliceVar(&opts.Profiles, "profile", nil, "重ねるプロファイル（カンマ区切りで複数、省略時は ARSENAL_PROFILE）")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "インストールと切り替えの計画を表示（何も変更しない）")
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "環境が .toolversions と一致しなければ失敗（何も変更しない）")
+	cmd.Flags().BoolVarP(&opts.Global, "global", "g", false, "~/.arsenal/toolversions のグローバルのデフォルトを同期")
+	cmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "dir 以下の全てのバージョンファイルのバージョンをインストール（切り替えない）")
+	cmd.MarkFlagsMutuallyExclusive("explain", "frozen")
+	cmd.MarkFlagsMutuallyExclusive("global", "frozen")
+	cmd.MarkFlagsMutuallyExclusive("explain", "dry-run", "check")
+	for _, flag := range []string{"explain", "frozen", "global"} {
+		cmd.MarkFlagsMutuallyExclusive("recursive", flag)
+	}
+
+	return cmd
 }
 
-func runSync() error {
+func runSync(explain bool, opts version.SyncOptions) error {
 	// カレントディレクトリを取得
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
 
+	if explain {
+		return runSyncExplain(cwd, opts.Profiles, opts.Global)
+	}
+
 	// sync 実行
-	return manager.Sync(cwd)
+	return manager.Sync(cwd, opts)
+}
+
+// 統合された .toolversions（global なら ~/.arsenal/toolversions）の各エントリと指定元を表示する
+func runSyncExplain(dir string, profiles []string, global bool) error {
+	var tv *version.ToolVersions
+	var err error
+	if global {
+		tv, err = manager.ReadGlobalToolVersions(profiles)
+	} else {
+		tv, err = manager.ReadToolVersions(dir, profiles)
+	}
+	if err != nil {
+		return fmt.Errorf(".toolversions 読み込みエラー: %w", err)
+	}
+	if err := tv.CheckProfiles(profiles); err != nil {
+		return err
+	}
+
+	shadowed := make(map[string][]version.ToolVersionsEntry)
+	for _, e := range tv.Shadowed {
+		shadowed[e.Tool] = append(shadowed[e.Tool], e)
+	}
+
+	tools := make([]string, 0, len(tv.Tools))
+	width := 0
+	for tool := range tv.Tools {
+		tools = append(tools, tool)
+		if len(tool) > width {
+			width = len(tool)
+		}
+	}
+	sort.Strings(tools)
+
+	terminal.PrintlnBlue("同期されるバージョン（近いファイルほど優先）:")
+	fmt.Println()
+
+	for _, tool := range tools {
+		fmt.Printf("  %-*s  %s  %s\n", width, tool,
+			terminal.Green(strings.Join(tv.Tools[tool], " ")), terminal.Cyan(entryOrigin(tv.Origins[tool], tv.OriginProfiles[tool])))
+		for _, e := range shadowed[tool] {
+			fmt.Printf("  %-*s  %s  %s\n", width, "",
+				terminal.Yellow(strings.Join(e.Versions, " ")+" (上書き)"), entryOrigin(e.Origin, e.Profile))
+		}
+	}
+
+	if len(tv.Profiles) > 0 {
+		fmt.Println()
+		fmt.Printf("定義されているプロファイル: %s\n", strings.Join(tv.Profiles, ", "))
+	}
+
+	return nil
+}
+
+// 指定元のファイルとプロファイルを表示用にする（例: /repo/.toolversions [ci]）
+func entryOrigin(path, profile string) string {
+	if profile == "" {
+		return path
+	}
+	return fmt.Sprintf("%s [%s]", path, profile)
 }
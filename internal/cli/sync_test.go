@@ -1,10 +1,13 @@
 package cli
 
 import (
+	"bytes"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/arsenal/internal/config"
@@ -16,8 +19,14 @@ import (
 func TestNewSyncCmd(t *testing.T) {
 	cmd := newSyncCmd()
 
-	if cmd.Use != "sync" {
-		t.Errorf("Use = %q, want %q", cmd.Use, "sync")
+	if cmd.Use != "sync [dir]" {
+		t.Errorf("Use = %q, want %q", cmd.Use, "sync [dir]")
+	}
+
+	for _, name := range []string{"explain", "frozen", "profile", "dry-run", "check", "global", "recursive"} {
+		if cmd.Flags().Lookup(name) == nil {
+			t.Errorf("--%s フラグがありません", name)
+		}
 	}
 }
 
@@ -79,7 +88,7 @@ archive_type = "tar.gz"
 	_ = os.Chdir(tmpDir)
 
 	// runSync を実行
-	err = runSync()
+	err = runSync(false, version.SyncOptions{})
 	if err != nil {
 		t.Errorf("runSync() エラー: %v", err)
 	}
@@ -126,7 +135,7 @@ func TestRunSyncNoToolversions(t *testing.T) {
 	_ = os.Chdir(tmpDir)
 
 	// runSync を実行（.toolversions がない）
-	err = runSync()
+	err = runSync(false, version.SyncOptions{})
 	if err == nil {
 		t.Error(".toolversions がないのにエラーが返されませんでした")
 	}
@@ -174,7 +183,7 @@ func TestRunSyncAlreadyInstalled(t *testing.T) {
 	_ = os.Chdir(tmpDir)
 
 	// runSync を実行
-	err = runSync()
+	err = runSync(false, version.SyncOptions{})
 	if err != nil {
 		t.Errorf("runSync() エラー: %v", err)
 	}
@@ -185,3 +194,78 @@ func TestRunSyncAlreadyInstalled(t *testing.T) {
 		t.Error("symlink が作成されませんでした")
 	}
 }
+
+// runSync --explain が各ツールの指定元を表示し、インストールしないかテストする
+func TestRunSyncExplain(t *testing.T) {
+	tmpDir := t.TempDir()
+	paths = &config.Paths{
+		Root:     filepath.Join(tmpDir, "arsenal"),
+		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
+		Current:  filepath.Join(tmpDir, "arsenal", "current"),
+		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+	}
+
+	if err := paths.EnsureDirs(); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	var err error
+	registry, err = plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+	manager = version.NewManager(paths, registry)
+
+	// ルートと frontend の .toolversions を作成
+	projectDir := filepath.Join(tmpDir, "project")
+	frontendDir := filepath.Join(projectDir, "frontend")
+	if err := os.MkdirAll(frontendDir, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	rootFile := filepath.Join(projectDir, ".toolversions")
+	if err := os.WriteFile(rootFile, []byte("node 18.19.0\ngo 1.22.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+	frontendFile := filepath.Join(frontendDir, ".toolversions")
+	if err := os.WriteFile(frontendFile, []byte("node 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(frontendDir)
+
+	// 標準出力をキャプチャ
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err = runSync(true, version.SyncOptions{})
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("runSync(true, version.SyncOptions{}) エラー: %v", err)
+	}
+
+	for _, want := range []string{"20.10.0", frontendFile, "1.22.0", rootFile, "18.19.0 (上書き)"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, output)
+		}
+	}
+
+	// インストールされていないか確認
+	if _, err := os.Stat(filepath.Join(paths.Versions, "node")); !os.IsNotExist(err) {
+		t.Error("--explain でインストールが行われました")
+	}
+
+	// 定義されていないプロファイルはエラー
+	if err := runSync(true, version.SyncOptions{Profiles: []string{"ci"}}); err == nil {
+		t.Error("未定義のプロファイルでエラーが返されませんでした")
+	}
+}
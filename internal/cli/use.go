@@ -3,14 +3,16 @@ package cli
 import (
 	"fmt"
 	"os"
-	"strings"
+	"path/filepath"
 
+	"github.com/arsenal/internal/config"
 	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
 	"github.com/spf13/cobra"
 )
 
 func newUseCmd() *cobra.Command {
-	var local bool
+	var local, global bool
 
 	cmd := &cobra.Command{
 		Use:   "use <tool> <version>",
@@ -20,21 +22,28 @@ func newUseCmd() *cobra.Command {
 --local フラグを指定すると、現在のディレクトリの .toolversions ファイルに
 バージョンを記録します。
 
+--global フラグを指定すると、グローバルのデフォルト（~/.arsenal/toolversions）に
+バージョンを記録します。どのプロジェクトにも指定がないツールで使われ、
+新しいマシンでは arsenal sync --global でまとめてインストールできます。
+
 使用例:
   arsenal use node 20.10.0
-  arsenal use go 1.22.0 --local`,
+  arsenal use go 1.22.0 --local
+  arsenal use node 20.10.0 --global`,
 		Args: cobra.ExactArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return runUse(args[0], args[1], local)
+			return runUse(args[0], args[1], local, global)
 		},
 	}
 
 	cmd.Flags().BoolVarP(&local, "local", "l", false, ".toolversions に記録")
+	cmd.Flags().BoolVarP(&global, "global", "g", false, "~/.arsenal/toolversions に記録")
+	cmd.MarkFlagsMutuallyExclusive("local", "global")
 
 	return cmd
 }
 
-func runUse(toolName, version string, local bool) error {
+func runUse(toolName, version string, local, global bool) error {
 	// プラグイン情報を取得（存在確認）
 	p, err := registry.Get(toolName)
 	if err != nil {
@@ -48,73 +57,36 @@ func runUse(toolName, version string, local bool) error {
 
 	// --local が指定された場合は .toolversions に書き込む
 	if local {
-		if err := updateToolVersionsFile(toolName, version); err != nil {
-			return fmt.Errorf(".toolversions 更新エラー: %w", err)
-		}
-		terminal.PrintSuccess(".toolversions に %s %s を記録しました", p.DisplayName, version)
-	}
-
-	return nil
-}
-
-func updateToolVersionsFile(toolName, ver string) error {
-	cwd, err := os.Getwd()
-	if err != nil {
-		return err
-	}
-
-	// 既存の .toolversions を読み込む（なければ新規作成）
-	toolversionsPath := cwd + "/.toolversions"
-
-	// ファイルが存在しない場合は新規作成
-	var tools map[string]string
-	if _, err := os.Stat(toolversionsPath); os.IsNotExist(err) {
-		tools = make(map[string]string)
-	} else {
-		// 既存のファイルを読み込む
-		tools, err = readToolVersionsSimple(toolversionsPath)
+		cwd, err := os.Getwd()
 		if err != nil {
 			return err
 		}
+		if err := updateToolVersionsFile(filepath.Join(cwd, config.ToolVersionFile), toolName, version); err != nil {
+			return fmt.Errorf(".toolversions 更新エラー: %w", err)
+		}
+		terminal.PrintSuccess(".toolversions に %s %s を記録しました", p.DisplayName, version)
 	}
 
-	// バージョンを更新
-	tools[toolName] = ver
-
-	// .toolversions に書き込む
-	f, err := os.Create(toolversionsPath)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = f.Close() }()
-
-	for tool, version := range tools {
-		if _, err := fmt.Fprintf(f, "%s %s\n", tool, version); err != nil {
-			return err
+	// --global が指定された場合は ~/.arsenal/toolversions に書き込む
+	if global {
+		path := manager.GlobalToolVersionsPath()
+		if err := updateToolVersionsFile(path, toolName, version); err != nil {
+			return fmt.Errorf("%s 更新エラー: %w", path, err)
 		}
+		terminal.PrintSuccess("%s に %s %s を記録しました", path, p.DisplayName, version)
 	}
 
 	return nil
 }
 
-func readToolVersionsSimple(path string) (map[string]string, error) {
-	content, err := os.ReadFile(path)
+// バージョンファイルにバージョンを記録する
+// 既存の行の順序やコメントはそのまま残す
+func updateToolVersionsFile(path, toolName, ver string) error {
+	doc, err := version.LoadToolVersionsDocument(path)
 	if err != nil {
-		return nil, err
-	}
-
-	tools := make(map[string]string)
-	lines := string(content)
-	for _, line := range strings.Split(lines, "\n") {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-		parts := strings.Fields(line)
-		if len(parts) == 2 {
-			tools[parts[0]] = parts[1]
-		}
+		return err
 	}
 
-	return tools, nil
+	doc.Set(toolName, ver)
+	return doc.Save(path)
 }
@@ -49,7 +49,7 @@ func TestRunUseSuccess(t *testing.T) {
 	manager = version.NewManager(paths, registry)
 
 	// runUse を実行
-	err = runUse("node", "20.10.0", false)
+	err = runUse("node", "20.10.0", false, false)
 	if err != nil {
 		t.Errorf("runUse() エラー: %v", err)
 	}
@@ -85,7 +85,7 @@ func TestRunUseNotInstalled(t *testing.T) {
 	manager = version.NewManager(paths, registry)
 
 	// runUse を実行（未インストールバージョン）
-	err = runUse("node", "99.99.99", false)
+	err = runUse("node", "99.99.99", false, false)
 	if err == nil {
 		t.Error("未インストールバージョンなのにエラーが返されませんでした")
 	}
@@ -126,7 +126,7 @@ func TestRunUseWithLocal(t *testing.T) {
 	_ = os.Chdir(tmpDir)
 
 	// runUse を実行（--local フラグ付き）
-	err = runUse("node", "20.10.0", true)
+	err = runUse("node", "20.10.0", true, false)
 	if err != nil {
 		t.Errorf("runUse() エラー: %v", err)
 	}
@@ -173,8 +173,63 @@ func TestRunUseUnknownTool(t *testing.T) {
 	manager = version.NewManager(paths, registry)
 
 	// runUse を実行（存在しないツール）
-	err = runUse("nonexistent", "1.0.0", false)
+	err = runUse("nonexistent", "1.0.0", false, false)
 	if err == nil {
 		t.Error("存在しないツールでエラーが返されませんでした")
 	}
 }
+
+// runUse --local が既存の .toolversions のコメントと順序を保つかテストする
+func TestRunUseWithLocalPreservesFile(t *testing.T) {
+	setupExecTest(t, "20.10.0")
+
+	projectDir := t.TempDir()
+	toolversionsPath := filepath.Join(projectDir, ".toolversions")
+	original := "# プロジェクトのツール\npython 3.12.0\nnode 18.19.0 # LTS\n\ngo 1.22.0\n"
+	if err := os.WriteFile(toolversionsPath, []byte(original), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	if err := runUse("node", "20.10.0", true, false); err != nil {
+		t.Fatalf("runUse() エラー: %v", err)
+	}
+
+	content, err := os.ReadFile(toolversionsPath)
+	if err != nil {
+		t.Fatalf(".toolversions 読み込みエラー: %v", err)
+	}
+
+	expected := "# プロジェクトのツール\npython 3.12.0\nnode 20.10.0 # LTS\n\ngo 1.22.0\n"
+	if string(content) != expected {
+		t.Errorf(".toolversions の内容が正しくありません\ngot:  %q\nwant: %q", string(content), expected)
+	}
+}
+
+// runUse --global がグローバルのデフォルトに記録し、.toolversions は作らないかテストする
+func TestRunUseWithGlobal(t *testing.T) {
+	setupExecTest(t, "20.10.0")
+
+	projectDir := t.TempDir()
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	if err := runUse("node", "20.10.0", false, true); err != nil {
+		t.Fatalf("runUse() エラー: %v", err)
+	}
+
+	content, err := os.ReadFile(paths.GlobalToolVersionsPath())
+	if err != nil {
+		t.Fatalf("グローバルのデフォルト読み込みエラー: %v", err)
+	}
+	if string(content) != "node 20.10.0\n" {
+		t.Errorf("グローバルのデフォルトの内容 = %q, want %q", string(content), "node 20.10.0\n")
+	}
+	if _, err := os.Stat(filepath.Join(projectDir, ".toolversions")); !os.IsNotExist(err) {
+		t.Error("--global で .toolversions が作成されました")
+	}
+}
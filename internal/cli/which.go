@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/arsenal/internal/terminal"
+	"github.com/arsenal/internal/version"
+	"github.com/spf13/cobra"
+)
+
+func newWhichCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "which <binary>",
+		Short: "実行ファイルを提供するツールとバージョンを表示",
+		Long: `実行ファイルがどのツールのどのバージョンから使われるかを表示します。
+
+カレントディレクトリで解決されるバージョンと、そのバージョンが選ばれた理由
+（シェルでの上書き、.toolversions、グローバルの current）を表示します。
+PATH 上で Arsenal の管理するディレクトリより前に同名の実行ファイルがある場合は
+それらも表示します。
+
+使用例:
+  bastion-arsenal which npm
+  bastion-arsenal which go`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runWhich(args[0])
+		},
+	}
+}
+
+func runWhich(name string) error {
+	dir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("カレントディレクトリ取得エラー: %w", err)
+	}
+
+	result, err := manager.Which(dir, name)
+	if err != nil {
+		return fmt.Errorf("バージョン解決エラー: %w", err)
+	}
+
+	if result.Tool == "" {
+		terminal.PrintWarning("%s は Arsenal で管理されていません", name)
+		path, err := exec.LookPath(name)
+		if err != nil {
+			return fmt.Errorf("%s が PATH に見つかりません", name)
+		}
+		fmt.Printf("  パス:       %s\n", path)
+		return nil
+	}
+
+	r := result.Resolution
+	fmt.Println(terminal.Blue(name))
+	fmt.Printf("  ツール:     %s\n", toolDisplayName(result.Tool))
+
+	if result.Path == "" {
+		// 実行ファイルを提供するツールだが、解決されたバージョンからは使えない
+		switch {
+		case r.Version == "":
+			fmt.Printf("  バージョン: %s\n", terminal.Yellow("未設定"))
+			return fmt.Errorf("%s のバージョンが選択されていません (bastion-arsenal use %s <version>)", result.Tool, result.Tool)
+		case !r.Installed:
+			fmt.Printf("  バージョン: %s\n", terminal.Red(r.Version+" (未インストール)"))
+			fmt.Printf("  選択理由:   %s\n", sourceDescription(r))
+			return fmt.Errorf("%s %s がインストールされていません (bastion-arsenal install %s %s)", result.Tool, r.Version, result.Tool, r.Version)
+		default:
+			fmt.Printf("  バージョン: %s\n", terminal.Green(r.Version))
+			fmt.Printf("  選択理由:   %s\n", sourceDescription(r))
+			return fmt.Errorf("%s は %s %s にありません", name, result.Tool, r.Version)
+		}
+	}
+
+	ver := terminal.Green(result.Version)
+	if result.Version != r.Version {
+		ver += terminal.Cyan(fmt.Sprintf(" (%s %s にないためフォールバック)", result.Tool, r.Version))
+	}
+	fmt.Printf("  バージョン: %s\n", ver)
+	fmt.Printf("  パス:       %s\n", result.Path)
+	fmt.Printf("  選択理由:   %s\n", sourceDescription(r))
+
+	if len(result.Shadowing) > 0 {
+		fmt.Println()
+		if result.OnPath {
+			terminal.PrintWarning("PATH で Arsenal より前に別の %s があります（こちらが実行されます）:", name)
+		} else {
+			terminal.PrintWarning("Arsenal のディレクトリが PATH にないため、別の %s が実行されます:", name)
+		}
+		for _, p := range result.Shadowing {
+			fmt.Printf("  %s\n", terminal.Yellow(p))
+		}
+	} else if !result.OnPath {
+		fmt.Println()
+		terminal.PrintWarning("Arsenal のディレクトリが PATH にありません (bastion-arsenal init-shell を設定してください)")
+	}
+
+	return nil
+}
+
+// バージョンが選ばれた理由を表示用に返す
+func sourceDescription(r version.Resolution) string {
+	switch r.Source {
+	case version.SourceEnv:
+		return fmt.Sprintf("シェルで上書き (%s)", r.Origin)
+	case version.SourceToolVersions:
+		// .toolversions 以外に .tool-versions や .nvmrc などからも読まれる
+		return fmt.Sprintf("%s (%s)", filepath.Base(r.Origin), r.Origin)
+	case version.SourceGlobal:
+		return fmt.Sprintf("グローバル (%s)", r.Origin)
+	case version.SourceGlobalFile:
+		return fmt.Sprintf("グローバルのデフォルト (%s)", r.Origin)
+	case version.SourceExec:
+		return "arsenal exec の引数"
+	}
+	return "管理されていません"
+}
+
+// プラグインの表示名を返す（プラグインがなければツール名）
+func toolDisplayName(tool string) string {
+	if p, err := registry.Get(tool); err == nil && p.DisplayName != "" {
+		return fmt.Sprintf("%s (%s)", p.DisplayName, tool)
+	}
+	return tool
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+)
+
+// runWhich が固定されたバージョンと選択理由を表示するかテストする
+func TestRunWhich(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	setupExecTest(t, "20.10.0")
+	npm := filepath.Join(paths.Versions, "node", "20.10.0", "bin", "npm")
+	if err := os.WriteFile(npm, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("実行ファイル作成エラー: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("node 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+	t.Setenv("PATH", paths.Bin)
+
+	originalWd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalWd) }()
+	_ = os.Chdir(projectDir)
+
+	// 標準出力をキャプチャ
+	oldStdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+
+	err := runWhich("npm")
+
+	_ = w.Close()
+	os.Stdout = oldStdout
+
+	var buf bytes.Buffer
+	_, _ = io.Copy(&buf, r)
+	output := buf.String()
+
+	if err != nil {
+		t.Fatalf("runWhich() エラー: %v", err)
+	}
+	for _, want := range []string{"Node.js (node)", "20.10.0", npm, ".toolversions ("} {
+		if !strings.Contains(output, want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, output)
+		}
+	}
+}
+
+// 固定されたバージョンが未インストールならエラーになるかテストする
+func TestRunWhichNotInstalled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	setupExecTest(t, "20.10.0")
+	if err := os.WriteFile(filepath.Join(paths.Versions, "node", "20.10.0", "bin", "npm"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("実行ファイル作成エラー: %v", err)
+	}
+
+	t.Setenv("ARSENAL_NODE_VERSION", "18.19.0")
+
+	err := runWhich("npm")
+	if err == nil || !strings.Contains(err.Error(), "インストールされていません") {
+		t.Errorf("runWhich() = %v, want 未インストールのエラー", err)
+	}
+
+	// どこにもない実行ファイル
+	t.Setenv("PATH", t.TempDir())
+	if err := runWhich("arsenal-no-such-binary"); err == nil {
+		t.Error("存在しない実行ファイルでエラーが返されませんでした")
+	}
+}
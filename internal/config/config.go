@@ -1,14 +1,26 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+
+	"github.com/BurntSushi/toml"
 )
 
 const (
-	AppName         = "arsenal"
-	ToolVersionFile = ".toolversions"
-	ConfigFile      = "config.toml"
+	AppName               = "arsenal"
+	ToolVersionFile       = ".toolversions"
+	AsdfToolVersionFile   = ".tool-versions" // asdf 互換（同じディレクトリに .toolversions があればそちらを優先）
+	ToolVersionLockFile   = ".toolversions.lock"
+	GlobalToolVersionFile = "toolversions" // ~/.arsenal 直下のグローバルのデフォルト
+	ConfigFile            = "config.toml"
+)
+
+// バージョンの切り替え方式
+const (
+	ModeSymlink = "symlink" // ~/.arsenal/bin のリンクファームとシェルフック（デフォルト）
+	ModeShims   = "shims"   // ~/.arsenal/shims のシムがプロセスごとに解決
 )
 
 // Arsenal の重要なディレクトリパスを保持する
@@ -18,12 +30,43 @@ type Paths struct {
 	Current  string // ~/.arsenal/current
 	Plugins  string // ~/.arsenal/plugins
 	Config   string // ~/.arsenal/config.toml
+	Bin      string // ~/.arsenal/bin (アクティブな実行ファイルへの symlink)
+	Shims    string // ~/.arsenal/shims (shims モードのシム)
+	Links    string // ~/.arsenal/links (バージョンごとの公開する実行ファイルへの symlink)
+
+	PluginSources string // ~/.arsenal/plugin-sources (plugin add で取得した定義)
 }
 
 // グローバル設定を保持する
 type Config struct {
 	DefaultShell string `toml:"default_shell"`
 	AutoSync     bool   `toml:"auto_sync"`
+	Mode         string `toml:"mode"`
+}
+
+// 設定ファイルを読み込む（存在しない場合はデフォルト値を返す）
+// 構文エラーや不明な mode の場合も、デフォルト値の設定をエラーと一緒に返す
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{Mode: ModeSymlink}
+
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		if os.IsNotExist(err) {
+			return cfg, nil
+		}
+		return &Config{Mode: ModeSymlink}, fmt.Errorf("%s の読み込みエラー: %w", path, err)
+	}
+
+	switch cfg.Mode {
+	case "":
+		cfg.Mode = ModeSymlink
+	case ModeSymlink, ModeShims:
+	default:
+		mode := cfg.Mode
+		cfg.Mode = ModeSymlink
+		return cfg, fmt.Errorf("%s: 不明な mode %q (%s, %s のみ対応)", path, mode, ModeSymlink, ModeShims)
+	}
+
+	return cfg, nil
 }
 
 // 標準的なディレクトリレイアウトを返す
@@ -40,6 +83,11 @@ func GetPaths() (*Paths, error) {
 		Current:  filepath.Join(root, "current"),
 		Plugins:  filepath.Join(root, "plugins"),
 		Config:   filepath.Join(root, ConfigFile),
+		Bin:      filepath.Join(root, "bin"),
+		Shims:    filepath.Join(root, "shims"),
+		Links:    filepath.Join(root, "links"),
+
+		PluginSources: filepath.Join(root, "plugin-sources"),
 	}, nil
 }
 
@@ -54,20 +102,33 @@ func (p *Paths) EnsureDirs() error {
 	return nil
 }
 
+// グローバルのデフォルトのバージョンファイルのパスを返す
+// 例: ~/.arsenal/toolversions
+func (p *Paths) GlobalToolVersionsPath() string {
+	return filepath.Join(p.Root, GlobalToolVersionFile)
+}
+
 // ツールのバージョンディレクトリのパスを返す
 // 例: ~/.arsenal/versions/node/20.10.0
 func (p *Paths) ToolVersionPath(tool, version string) string {
 	return filepath.Join(p.Versions, tool, version)
 }
 
+// バージョンが公開する実行ファイルへの symlink を置くディレクトリを返す
+// 例: ~/.arsenal/links/node/20.10.0
+func (p *Paths) ToolLinksPath(tool, version string) string {
+	return filepath.Join(p.Links, tool, version)
+}
+
 // ツールの symlink パスを返す
 // 例: ~/.arsenal/current/node
 func (p *Paths) ToolCurrentPath(tool string) string {
 	return filepath.Join(p.Current, tool)
 }
 
-// ツールバージョンの bin ディレクトリを返す
+// アクティブバージョンの bin ディレクトリを返す
+// binPath はプラグインの bin_path / bin_paths の各要素
 // 例: ~/.arsenal/current/node/bin
-func (p *Paths) ToolBinPath(tool string) string {
-	return filepath.Join(p.Current, tool, "bin")
+func (p *Paths) ToolBinPath(tool, binPath string) string {
+	return filepath.Join(p.Current, tool, binPath)
 }
@@ -2,10 +2,14 @@ package plugin
 
 import (
 	"embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/BurntSushi/toml"
@@ -20,12 +24,18 @@ type Plugin struct {
 
 	// バージョン検出とダウンロード用 URL
 	ListURL     string `toml:"list_url"`
-	ListFormat  string `toml:"list_format"` // "json", "html", "github"
+	ListFormat  string `toml:"list_format"` // "json"
 	DownloadURL string `toml:"download_url"`
 
 	// 展開されたアーカイブ内でバイナリが配置されているパス
 	BinPath string `toml:"bin_path"`
 
+	// バイナリが複数ディレクトリにある場合のパス一覧（bin_path より優先）
+	BinPaths []string `toml:"bin_paths"`
+
+	// PATH に公開する実行ファイル名の許可リスト（空なら全て公開）
+	Executables []string `toml:"executables"`
+
 	// 展開方法: "tar.gz", "tar.xz", "zip"
 	ArchiveType string `toml:"archive_type"`
 
@@ -40,22 +50,67 @@ type Plugin struct {
 	// インストール後コマンド
 	PostInstall []string `toml:"post_install"`
 
-	// 設定する環境変数
+	// 設定する環境変数（{{install_dir}}, {{version}}, {{current_dir}} を展開）
 	EnvVars map[string]string `toml:"env_vars"`
+
+	// .toolversions がない場合に読むツール固有のバージョンファイル（例: .nvmrc, go.mod）
+	LegacyFiles []string `toml:"legacy_files"`
+
+	// asdf の .tool-versions で使われるプラグイン名（例: node の nodejs）
+	AsdfNames []string `toml:"asdf_names"`
 }
 
 //go:embed builtin
 var builtinPlugins embed.FS
 
+// プラグイン定義の読み込み元の種類
+const (
+	OriginBuiltin = "builtin" // go:embed で組み込まれた定義
+	OriginSource  = "source"  // plugin add で取得した定義
+	OriginUser    = "user"    // ~/.arsenal/plugins に置かれた定義
+)
+
+// プラグイン定義の読み込み元を表す
+type Origin struct {
+	Kind string // OriginBuiltin / OriginSource / OriginUser
+	Path string // 定義ファイルのパス
+
+	// 同名の定義を上書きした場合、上書きされた側の読み込み元
+	Overrides *Origin
+}
+
+// プラグイン定義ファイルの読み込みエラーを表す
+// エラーになった定義は読み込まれず、他のプラグインはそのまま利用できる
+type LoadError struct {
+	Path string // 定義ファイル（またはディレクトリ）のパス
+	Line int    // エラー箇所の行番号（不明な場合は 0）
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d: %v", e.Path, e.Line, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
 // 利用可能なプラグインを管理する
 type Registry struct {
-	plugins map[string]*Plugin
+	plugins    map[string]*Plugin
+	origins    map[string]*Origin
+	loadErrors []*LoadError
 }
 
 // 新しいプラグインレジストリを作成し、組み込みプラグインを読み込む
+// ユーザー定義の読み込みエラーは LoadErrors で取得でき、エラーとしては返さない
 func NewRegistry(paths *config.Paths) (*Registry, error) {
 	r := &Registry{
 		plugins: make(map[string]*Plugin),
+		origins: make(map[string]*Origin),
 	}
 
 	// 埋め込みファイルから組み込みプラグインを読み込み
@@ -63,10 +118,11 @@ func NewRegistry(paths *config.Paths) (*Registry, error) {
 		return nil, fmt.Errorf("組み込みプラグイン読み込みエラー: %w", err)
 	}
 
-	// ユーザープラグインを読み込み（組み込みを上書き）
-	if err := r.loadUserPlugins(paths.Plugins); err != nil {
-		return nil, fmt.Errorf("ユーザープラグイン読み込みエラー: %w", err)
-	}
+	// plugin add で追加したプラグインを読み込み（組み込みを上書き）
+	r.loadSourcePlugins(paths.PluginSources)
+
+	// ユーザープラグインを読み込み（組み込みとソースを上書き）
+	r.loadUserPlugins(paths.Plugins, OriginUser)
 
 	return r, nil
 }
@@ -92,19 +148,24 @@ func (r *Registry) loadBuiltinPlugins() error {
 			return fmt.Errorf("parsing %s: %w", entry.Name(), err)
 		}
 
-		r.plugins[p.Name] = &p
+		r.register(&p, &Origin{Kind: OriginBuiltin, Path: "builtin/" + entry.Name()})
 	}
 
 	return nil
 }
 
-func (r *Registry) loadUserPlugins(dir string) error {
+// ディレクトリ内の定義を読み込み、失敗したファイルは LoadError として記録する
+func (r *Registry) loadUserPlugins(dir, kind string) {
+	if dir == "" {
+		return
+	}
+
 	entries, err := os.ReadDir(dir)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
+		if !os.IsNotExist(err) {
+			r.loadErrors = append(r.loadErrors, &LoadError{Path: dir, Err: err})
 		}
-		return err
+		return
 	}
 
 	for _, entry := range entries {
@@ -112,15 +173,206 @@ func (r *Registry) loadUserPlugins(dir string) error {
 			continue
 		}
 
-		var p Plugin
-		if _, err := toml.DecodeFile(filepath.Join(dir, entry.Name()), &p); err != nil {
-			return fmt.Errorf("parsing %s: %w", entry.Name(), err)
+		path := filepath.Join(dir, entry.Name())
+		p, err := decodePluginFile(path)
+		if err != nil {
+			r.loadErrors = append(r.loadErrors, newLoadError(path, err))
+			continue
+		}
+		if p.Name == "" {
+			r.loadErrors = append(r.loadErrors, &LoadError{Path: path, Err: fmt.Errorf("name が指定されていません")})
+			continue
+		}
+		if !IsValidName(p.Name) {
+			r.loadErrors = append(r.loadErrors, &LoadError{Path: path, Err: fmt.Errorf("name に使用できない文字が含まれています: %q", p.Name)})
+			continue
 		}
 
-		r.plugins[p.Name] = &p
+		r.register(p, &Origin{Kind: kind, Path: path})
 	}
+}
 
-	return nil
+// プラグインソースのディレクトリごとに定義を読み込む
+func (r *Registry) loadSourcePlugins(dir string) {
+	if dir == "" {
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			r.loadErrors = append(r.loadErrors, &LoadError{Path: dir, Err: err})
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !isValidSourceName(entry.Name()) {
+			continue
+		}
+		r.loadUserPlugins(filepath.Join(dir, entry.Name()), OriginSource)
+	}
+}
+
+// TOML のパースエラーから行番号を取り出して LoadError を作る
+func newLoadError(path string, err error) *LoadError {
+	var perr toml.ParseError
+	if errors.As(err, &perr) {
+		return &LoadError{Path: path, Line: perr.Position.Line, Err: errors.New(perr.Message)}
+	}
+	return &LoadError{Path: path, Err: err}
+}
+
+// プラグインを登録し、同名の定義があれば上書き元として記録する
+func (r *Registry) register(p *Plugin, origin *Origin) {
+	origin.Overrides = r.origins[p.Name]
+	r.plugins[p.Name] = p
+	r.origins[p.Name] = origin
+}
+
+// 読み込みに失敗したユーザー定義のエラーを返す
+func (r *Registry) LoadErrors() []*LoadError {
+	return r.loadErrors
+}
+
+// 組み込みプラグインを上書きしている定義の読み込み元をプラグイン名をキーに返す
+func (r *Registry) BuiltinOverrides() map[string]*Origin {
+	result := make(map[string]*Origin)
+	for name, origin := range r.origins {
+		for o := origin.Overrides; o != nil; o = o.Overrides {
+			if o.Kind == OriginBuiltin {
+				result[name] = origin
+				break
+			}
+		}
+	}
+	return result
+}
+
+// TOML ファイルからプラグイン定義を読み込む
+func decodePluginFile(path string) (*Plugin, error) {
+	var p Plugin
+	if _, err := toml.DecodeFile(path, &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// 既知の list_format / archive_type（list_format は ListRemote が解析できるもののみ）
+var (
+	knownListFormats  = []string{"json"}
+	knownArchiveTypes = []string{"tar.gz", "tgz", "tar.xz", "zip"}
+)
+
+// プラグイン名に使える文字（シムやシェルのスクリプトにそのまま埋め込めるもの）
+var pluginNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// プラグイン名が英数字で始まり、英数字と '.', '-', '_' のみからなるか判定する
+func IsValidName(name string) bool {
+	return pluginNamePattern.MatchString(name)
+}
+
+// テンプレート変数の参照（{{name}}）
+var templateVarPattern = regexp.MustCompile(`\{\{\s*([^}]*?)\s*\}\}`)
+
+// プラグイン定義の問題点を返す（問題がなければ空）
+func (p *Plugin) Validate() []error {
+	var errs []error
+
+	if p.Name == "" {
+		errs = append(errs, fmt.Errorf("name が指定されていません"))
+	} else if !IsValidName(p.Name) {
+		errs = append(errs, fmt.Errorf("name に使用できない文字が含まれています: %q (英数字, '.', '-', '_' のみ使用可)", p.Name))
+	}
+
+	if p.DownloadURL == "" {
+		errs = append(errs, fmt.Errorf("download_url が指定されていません"))
+	} else {
+		if !strings.Contains(p.DownloadURL, "{{version}}") {
+			errs = append(errs, fmt.Errorf("download_url に {{version}} が含まれていません"))
+		}
+		errs = append(errs, checkTemplateVars("download_url", p.DownloadURL, "version", "os", "arch")...)
+	}
+
+	if p.ListFormat != "" && !slices.Contains(knownListFormats, p.ListFormat) {
+		errs = append(errs, fmt.Errorf("不明な list_format: %q (%s のいずれか)", p.ListFormat, strings.Join(knownListFormats, ", ")))
+	}
+	if p.ListFormat != "" && p.ListURL == "" {
+		errs = append(errs, fmt.Errorf("list_format を指定する場合は list_url が必要です"))
+	}
+
+	if p.ArchiveType != "" && !slices.Contains(knownArchiveTypes, p.ArchiveType) {
+		errs = append(errs, fmt.Errorf("不明な archive_type: %q (%s のいずれか)", p.ArchiveType, strings.Join(knownArchiveTypes, ", ")))
+	}
+
+	if p.VersionRegex != "" {
+		if _, err := regexp.Compile(p.VersionRegex); err != nil {
+			errs = append(errs, fmt.Errorf("version_regex が不正です: %v", err))
+		}
+	}
+
+	for _, bp := range p.ResolveBinPaths() {
+		if filepath.IsAbs(bp) || strings.HasPrefix(filepath.Clean(bp), "..") {
+			errs = append(errs, fmt.Errorf("bin パスはインストールディレクトリ内の相対パスで指定してください: %q", bp))
+		}
+	}
+
+	for _, name := range p.LegacyFiles {
+		if name == "" || name != filepath.Base(name) || name == "." || name == ".." {
+			errs = append(errs, fmt.Errorf("legacy_files にはファイル名のみを指定してください: %q", name))
+		}
+	}
+
+	for _, name := range p.AsdfNames {
+		if !IsValidName(name) {
+			errs = append(errs, fmt.Errorf("asdf_names に使用できない文字が含まれています: %q", name))
+		}
+	}
+
+	names := make([]string, 0, len(p.EnvVars))
+	for name := range p.EnvVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		field := fmt.Sprintf("env_vars.%s", name)
+		errs = append(errs, checkTemplateVars(field, p.EnvVars[name], "install_dir", "version", "current_dir")...)
+	}
+
+	return errs
+}
+
+// TOML ファイルを読み込み、構文エラー・未知のキー・定義の問題点を返す
+// 構文エラーの場合は返されるプラグインが nil になる
+func ValidateFile(path string) (*Plugin, []error) {
+	var p Plugin
+	md, err := toml.DecodeFile(path, &p)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var errs []error
+	for _, key := range md.Undecoded() {
+		// マップ型のフィールド配下は任意のキーを許可する
+		if len(key) > 1 {
+			continue
+		}
+		errs = append(errs, fmt.Errorf("未知のキー: %s (typo の可能性があります)", key.String()))
+	}
+
+	return &p, append(errs, p.Validate()...)
+}
+
+// 値に含まれるテンプレート変数が既知のものだけか確認する
+func checkTemplateVars(field, value string, allowed ...string) []error {
+	var errs []error
+	for _, m := range templateVarPattern.FindAllStringSubmatch(value, -1) {
+		if !slices.Contains(allowed, m[1]) {
+			errs = append(errs, fmt.Errorf("%s に不明なテンプレート変数 {{%s}} があります (使用可能: %s)",
+				field, m[1], strings.Join(allowed, ", ")))
+		}
+	}
+	return errs
 }
 
 // 名前でプラグインを返す
@@ -132,6 +384,30 @@ func (r *Registry) Get(name string) (*Plugin, error) {
 	return p, nil
 }
 
+// asdf のプラグイン名をプラグイン名に変換する
+// 同名のプラグインがあればそのまま、なければ asdf_names に含むプラグイン（名前順で最初のもの）の名前を返す
+// どちらもなければ name をそのまま返す
+func (r *Registry) FromAsdfName(name string) string {
+	if _, ok := r.plugins[name]; ok {
+		return name
+	}
+
+	names := r.List()
+	sort.Strings(names)
+	for _, pname := range names {
+		if slices.Contains(r.plugins[pname].AsdfNames, name) {
+			return pname
+		}
+	}
+	return name
+}
+
+// プラグイン定義の読み込み元を返す
+func (r *Registry) Origin(name string) (*Origin, bool) {
+	o, ok := r.origins[name]
+	return o, ok
+}
+
 // 利用可能な全プラグイン名を返す
 func (r *Registry) List() []string {
 	names := make([]string, 0, len(r.plugins))
@@ -146,12 +422,64 @@ func (r *Registry) All() map[string]*Plugin {
 	return r.plugins
 }
 
+// OS とアーキテクチャの組を表す（runtime.GOOS / runtime.GOARCH の値）
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+// 文字列表現を返す（例: linux/amd64）
+func (pl Platform) String() string {
+	return pl.OS + "/" + pl.Arch
+}
+
+// os_map / arch_map が指定されていない場合に想定するプラットフォーム
+var (
+	defaultOSes   = []string{"darwin", "linux", "windows"}
+	defaultArches = []string{"amd64", "arm64"}
+)
+
+// プラグインが対応する全プラットフォームを返す
+// os_map / arch_map のキーの組み合わせで、未指定なら主要プラットフォームを使う
+func (p *Plugin) Platforms() []Platform {
+	oses := sortedKeys(p.OSMap)
+	if len(oses) == 0 {
+		oses = defaultOSes
+	}
+	arches := sortedKeys(p.ArchMap)
+	if len(arches) == 0 {
+		arches = defaultArches
+	}
+
+	platforms := make([]Platform, 0, len(oses)*len(arches))
+	for _, o := range oses {
+		for _, a := range arches {
+			platforms = append(platforms, Platform{OS: o, Arch: a})
+		}
+	}
+	return platforms
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // ダウンロード URL 内のテンプレート変数を置換する
 func (p *Plugin) ResolveDownloadURL(version string) string {
+	return p.ResolveDownloadURLFor(version, Platform{OS: runtime.GOOS, Arch: runtime.GOARCH})
+}
+
+// 指定プラットフォーム用にダウンロード URL 内のテンプレート変数を置換する
+func (p *Plugin) ResolveDownloadURLFor(version string, platform Platform) string {
 	url := p.DownloadURL
 
-	osName := runtime.GOOS
-	archName := runtime.GOARCH
+	osName := platform.OS
+	archName := platform.Arch
 
 	// OS マッピングを適用
 	if mapped, ok := p.OSMap[osName]; ok {
@@ -172,6 +500,54 @@ func (p *Plugin) ResolveDownloadURL(version string) string {
 	return replacer.Replace(url)
 }
 
+// 環境変数の値に含まれるテンプレート変数を置換する
+// installDir はバージョンのインストール先、currentDir は current 配下の symlink パス
+func (p *Plugin) ResolveEnvVars(installDir, version, currentDir string) map[string]string {
+	replacer := strings.NewReplacer(
+		"{{install_dir}}", installDir,
+		"{{version}}", version,
+		"{{current_dir}}", currentDir,
+	)
+
+	vars := make(map[string]string, len(p.EnvVars))
+	for name, value := range p.EnvVars {
+		vars[name] = replacer.Replace(value)
+	}
+	return vars
+}
+
+// インストールディレクトリからの相対 bin パス一覧を返す
+// bin_paths > bin_path > "bin" の順で決定する
+func (p *Plugin) ResolveBinPaths() []string {
+	if len(p.BinPaths) > 0 {
+		return p.BinPaths
+	}
+	if p.BinPath != "" {
+		return []string{p.BinPath}
+	}
+	return []string{"bin"}
+}
+
+// 実行ファイルを PATH に公開するかどうかを返す
+// Windows では拡張子を除いた名前でも照合する
+func (p *Plugin) ExposesExecutable(name string) bool {
+	if len(p.Executables) == 0 {
+		return true
+	}
+
+	base := name
+	if runtime.GOOS == "windows" {
+		base = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+
+	for _, e := range p.Executables {
+		if e == name || e == base {
+			return true
+		}
+	}
+	return false
+}
+
 // 現在のプラットフォーム用のアーカイブタイプを返す
 func (p *Plugin) ResolveArchiveType() string {
 	if p.ArchiveType != "" {
@@ -1,6 +1,9 @@
 package plugin
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/arsenal/internal/config"
@@ -59,6 +62,314 @@ func TestPluginResolveDownloadURL(t *testing.T) {
 	}
 }
 
+// 環境変数のテンプレートが正しく展開されるかテストする
+func TestPluginResolveEnvVars(t *testing.T) {
+	plugin := &Plugin{
+		EnvVars: map[string]string{
+			"GOROOT":      "{{install_dir}}",
+			"GO_VERSION":  "go{{version}}",
+			"GOROOT_LINK": "{{current_dir}}/libexec",
+		},
+	}
+
+	vars := plugin.ResolveEnvVars("/arsenal/versions/go/1.22.0", "1.22.0", "/arsenal/current/go")
+
+	expected := map[string]string{
+		"GOROOT":      "/arsenal/versions/go/1.22.0",
+		"GO_VERSION":  "go1.22.0",
+		"GOROOT_LINK": "/arsenal/current/go/libexec",
+	}
+
+	if len(vars) != len(expected) {
+		t.Errorf("環境変数の数 = %d, want %d", len(vars), len(expected))
+	}
+	for name, want := range expected {
+		if vars[name] != want {
+			t.Errorf("vars[%q] = %q, want %q", name, vars[name], want)
+		}
+	}
+}
+
+// bin パスが優先順位に従って解決されるかテストする
+func TestPluginResolveBinPaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *Plugin
+		want   []string
+	}{
+		{
+			name:   "bin_paths を優先",
+			plugin: &Plugin{BinPath: "bin", BinPaths: []string{"cargo/bin", "rustc/bin"}},
+			want:   []string{"cargo/bin", "rustc/bin"},
+		},
+		{
+			name:   "bin_path を使用",
+			plugin: &Plugin{BinPath: "usr/bin"},
+			want:   []string{"usr/bin"},
+		},
+		{
+			name:   "未指定なら bin",
+			plugin: &Plugin{},
+			want:   []string{"bin"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.plugin.ResolveBinPaths()
+			if len(got) != len(tt.want) {
+				t.Fatalf("ResolveBinPaths() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("ResolveBinPaths()[%d] = %q, want %q", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// executables 許可リストが正しく判定されるかテストする
+func TestPluginExposesExecutable(t *testing.T) {
+	all := &Plugin{}
+	if !all.ExposesExecutable("anything") {
+		t.Error("許可リストが空なら全て公開されるべきです")
+	}
+
+	limited := &Plugin{Executables: []string{"python3", "pip3"}}
+	if !limited.ExposesExecutable("python3") {
+		t.Error("python3 が公開されていません")
+	}
+	if limited.ExposesExecutable("2to3") {
+		t.Error("許可リスト外の 2to3 が公開されています")
+	}
+}
+
+// 指定プラットフォーム用の URL が解決されるかテストする
+func TestPluginResolveDownloadURLFor(t *testing.T) {
+	plugin := &Plugin{
+		DownloadURL: "https://example.com/node-v{{version}}-{{os}}-{{arch}}.tar.gz",
+		OSMap:       map[string]string{"windows": "win", "linux": "linux"},
+		ArchMap:     map[string]string{"amd64": "x64"},
+	}
+
+	got := plugin.ResolveDownloadURLFor("20.10.0", Platform{OS: "windows", Arch: "amd64"})
+	want := "https://example.com/node-v20.10.0-win-x64.tar.gz"
+	if got != want {
+		t.Errorf("ResolveDownloadURLFor() = %q, want %q", got, want)
+	}
+
+	platforms := plugin.Platforms()
+	wantPlatforms := []string{"linux/amd64", "windows/amd64"}
+	if len(platforms) != len(wantPlatforms) {
+		t.Fatalf("Platforms() = %v, want %v", platforms, wantPlatforms)
+	}
+	for i, p := range platforms {
+		if p.String() != wantPlatforms[i] {
+			t.Errorf("Platforms()[%d] = %q, want %q", i, p.String(), wantPlatforms[i])
+		}
+	}
+
+	// マッピングがない場合は主要プラットフォームを返す
+	if got := (&Plugin{}).Platforms(); len(got) != 6 {
+		t.Errorf("Platforms() の件数 = %d, want 6", len(got))
+	}
+}
+
+// プラグイン定義の検証をテストする
+func TestPluginValidate(t *testing.T) {
+	valid := Plugin{
+		Name:        "terraform",
+		DownloadURL: "https://example.com/terraform_{{version}}_{{os}}_{{arch}}.zip",
+		ArchiveType: "zip",
+		EnvVars:     map[string]string{"TF_HOME": "{{install_dir}}"},
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(p *Plugin)
+		wantErr string
+	}{
+		{"正常", func(p *Plugin) {}, ""},
+		{"name なし", func(p *Plugin) { p.Name = "" }, "name"},
+		{"name に空白", func(p *Plugin) { p.Name = "my tool" }, "name"},
+		{"name にシェルのメタ文字", func(p *Plugin) { p.Name = "x;rm" }, "name"},
+		{"download_url なし", func(p *Plugin) { p.DownloadURL = "" }, "download_url"},
+		{"version 変数なし", func(p *Plugin) { p.DownloadURL = "https://example.com/latest.zip" }, "{{version}}"},
+		{"不明なテンプレート変数", func(p *Plugin) { p.DownloadURL += "?v={{verison}}" }, "{{verison}}"},
+		{"不明な list_format", func(p *Plugin) { p.ListURL = "https://example.com"; p.ListFormat = "yaml" }, "list_format"},
+		{"未対応の list_format", func(p *Plugin) { p.ListURL = "https://example.com"; p.ListFormat = "html" }, "list_format"},
+		{"list_url なし", func(p *Plugin) { p.ListFormat = "json" }, "list_url"},
+		{"不明な archive_type", func(p *Plugin) { p.ArchiveType = "rar" }, "archive_type"},
+		{"不正な正規表現", func(p *Plugin) { p.VersionRegex = "v(\\d+" }, "version_regex"},
+		{"bin パスが外部を指す", func(p *Plugin) { p.BinPaths = []string{"../bin"} }, "bin パス"},
+		{"env_vars の不明な変数", func(p *Plugin) { p.EnvVars = map[string]string{"X": "{{os}}"} }, "env_vars.X"},
+		{"legacy_files にパス", func(p *Plugin) { p.LegacyFiles = []string{"../.nvmrc"} }, "legacy_files"},
+		{"asdf_names に空白", func(p *Plugin) { p.AsdfNames = []string{"my tool"} }, "asdf_names"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := valid
+			tt.modify(&p)
+			errs := p.Validate()
+
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Errorf("Validate() = %v, want no errors", errs)
+				}
+				return
+			}
+
+			found := false
+			for _, err := range errs {
+				if strings.Contains(err.Error(), tt.wantErr) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("Validate() = %v, want error containing %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
+
+// 組み込みプラグインが全て検証に通るかテストする
+func TestBuiltinPluginsValid(t *testing.T) {
+	registry, err := NewRegistry(&config.Paths{Plugins: t.TempDir()})
+	if err != nil {
+		t.Fatalf("NewRegistry() エラー: %v", err)
+	}
+
+	for name, p := range registry.All() {
+		if errs := p.Validate(); len(errs) > 0 {
+			t.Errorf("組み込みプラグイン %s に問題があります: %v", name, errs)
+		}
+	}
+}
+
+// ファイルの検証で構文エラーと未知のキーが報告されるかテストする
+func TestValidateFile(t *testing.T) {
+	dir := t.TempDir()
+
+	typo := filepath.Join(dir, "typo.toml")
+	content := `name = "terraform"
+download_url = "https://example.com/terraform_{{version}}.zip"
+bin_pth = "bin"
+
+[os_map]
+linux = "linux"
+`
+	if err := os.WriteFile(typo, []byte(content), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+
+	p, errs := ValidateFile(typo)
+	if p == nil {
+		t.Fatal("ValidateFile() がプラグインを返しませんでした")
+	}
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "bin_pth") {
+		t.Errorf("ValidateFile() = %v, want bin_pth の指摘のみ", errs)
+	}
+
+	broken := filepath.Join(dir, "broken.toml")
+	if err := os.WriteFile(broken, []byte("name = \"x\"\ndownload_url = \n"), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+
+	p, errs = ValidateFile(broken)
+	if p != nil || len(errs) != 1 {
+		t.Fatalf("ValidateFile() = %v, %v, want 構文エラー", p, errs)
+	}
+	if !strings.Contains(errs[0].Error(), "line ") {
+		t.Errorf("構文エラーに行番号が含まれていません: %v", errs[0])
+	}
+}
+
+// 壊れた定義があっても他のプラグインが読み込まれるかテストする
+func TestNewRegistryLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"broken.toml": "name = \"broken\"\ndisplay_name = \"Broken\nlist_url = \"x\"\n",
+		"noname.toml": "display_name = \"No Name\"\n",
+		"good.toml":   "name = \"good\"\ndownload_url = \"https://example.com/{{version}}.tar.gz\"\n",
+		"quote.toml":  "name = \"a'b\"\ndownload_url = \"https://example.com/{{version}}.tar.gz\"\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("ファイル作成エラー: %v", err)
+		}
+	}
+
+	registry, err := NewRegistry(&config.Paths{Plugins: dir})
+	if err != nil {
+		t.Fatalf("NewRegistry() エラー: %v", err)
+	}
+
+	if _, err := registry.Get("good"); err != nil {
+		t.Errorf("正常な定義が読み込まれていません: %v", err)
+	}
+	if _, err := registry.Get("node"); err != nil {
+		t.Errorf("組み込みプラグインが読み込まれていません: %v", err)
+	}
+	if _, err := registry.Get("broken"); err == nil {
+		t.Error("壊れた定義が読み込まれています")
+	}
+
+	loadErrors := registry.LoadErrors()
+	if len(loadErrors) != 3 {
+		t.Fatalf("LoadErrors() = %v, want 3 件", loadErrors)
+	}
+
+	// ファイル名順に記録される
+	if loadErrors[0].Path != filepath.Join(dir, "broken.toml") || loadErrors[0].Line != 2 {
+		t.Errorf("LoadErrors()[0] = %q, want broken.toml の 2 行目", loadErrors[0].Error())
+	}
+	if !strings.HasPrefix(loadErrors[0].Error(), filepath.Join(dir, "broken.toml")+":2: ") {
+		t.Errorf("Error() = %q, ファイル名と行番号が含まれていません", loadErrors[0].Error())
+	}
+	if loadErrors[1].Path != filepath.Join(dir, "noname.toml") {
+		t.Errorf("LoadErrors()[1].Path = %q, want noname.toml", loadErrors[1].Path)
+	}
+	if loadErrors[2].Path != filepath.Join(dir, "quote.toml") {
+		t.Errorf("LoadErrors()[2].Path = %q, want quote.toml", loadErrors[2].Path)
+	}
+}
+
+// 読み込み元と上書きが記録されるかテストする
+func TestRegistryOrigin(t *testing.T) {
+	dir := t.TempDir()
+	override := filepath.Join(dir, "node.toml")
+	content := `name = "node"
+download_url = "https://mirror.example.com/node-v{{version}}.tar.gz"
+`
+	if err := os.WriteFile(override, []byte(content), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+
+	registry, err := NewRegistry(&config.Paths{Plugins: dir})
+	if err != nil {
+		t.Fatalf("NewRegistry() エラー: %v", err)
+	}
+
+	origin, ok := registry.Origin("node")
+	if !ok {
+		t.Fatal("node の読み込み元が記録されていません")
+	}
+	if origin.Kind != OriginUser || origin.Path != override {
+		t.Errorf("Origin = %+v, want user %s", origin, override)
+	}
+	if origin.Overrides == nil || origin.Overrides.Kind != OriginBuiltin {
+		t.Errorf("Overrides = %+v, want builtin", origin.Overrides)
+	}
+
+	overrides := registry.BuiltinOverrides()
+	if len(overrides) != 1 || overrides["node"] != origin {
+		t.Errorf("BuiltinOverrides() = %v, want node のみ", overrides)
+	}
+}
+
 // アーカイブタイプが正しく解決されるかテストする
 func TestPluginResolveArchiveType(t *testing.T) {
 	tests := []struct {
@@ -151,6 +462,36 @@ func TestRegistryGet(t *testing.T) {
 	}
 }
 
+// asdf のプラグイン名が asdf_names でプラグイン名に変換されるかテストする
+func TestRegistryFromAsdfName(t *testing.T) {
+	dir := t.TempDir()
+	// asdf_names に組み込みプラグインと同じ名前を含めても、同名のプラグインが優先される
+	content := "name = \"terraform\"\ndownload_url = \"https://example.com/{{version}}.zip\"\nasdf_names = [\"tf\", \"node\"]\n"
+	if err := os.WriteFile(filepath.Join(dir, "terraform.toml"), []byte(content), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+
+	registry, err := NewRegistry(&config.Paths{Plugins: dir})
+	if err != nil {
+		t.Fatalf("NewRegistry() エラー: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"nodejs", "node"},
+		{"node", "node"},
+		{"tf", "terraform"},
+		{"ruby", "ruby"},
+	}
+	for _, tt := range tests {
+		if got := registry.FromAsdfName(tt.name); got != tt.want {
+			t.Errorf("FromAsdfName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
 // 文字列に部分文字列が含まれるか確認するヘルパー関数
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) && findSubstring(s, substr))
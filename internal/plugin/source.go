@@ -0,0 +1,366 @@
+package plugin
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/arsenal/internal/config"
+)
+
+// プラグインソースの種類
+const (
+	SourceKindGit   = "git"
+	SourceKindLocal = "local"
+)
+
+// プラグインソースの一覧を記録するファイル名（PluginSources 直下）
+const sourcesIndexFile = ".sources.toml"
+
+var sourceNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// plugin add で追加したプラグイン定義の取得元を表す
+type Source struct {
+	Name      string    `toml:"-"`
+	Kind      string    `toml:"kind"`          // "git" または "local"
+	Location  string    `toml:"location"`      // git URL またはローカルパス
+	Ref       string    `toml:"ref,omitempty"` // git のブランチ/タグ
+	Revision  string    `toml:"revision"`      // git のコミット、local は内容のハッシュ
+	Plugins   []string  `toml:"plugins"`       // 含まれるプラグイン名
+	UpdatedAt time.Time `toml:"updated_at"`
+}
+
+type sourceIndex struct {
+	Sources map[string]*Source `toml:"sources"`
+}
+
+// 記録されている全プラグインソースを返す
+func LoadSources(paths *config.Paths) (map[string]*Source, error) {
+	idx := sourceIndex{Sources: make(map[string]*Source)}
+
+	path := filepath.Join(paths.PluginSources, sourcesIndexFile)
+	if _, err := toml.DecodeFile(path, &idx); err != nil {
+		if os.IsNotExist(err) {
+			return idx.Sources, nil
+		}
+		return nil, fmt.Errorf("%s の読み込みエラー: %w", path, err)
+	}
+
+	if idx.Sources == nil {
+		idx.Sources = make(map[string]*Source)
+	}
+	for name, s := range idx.Sources {
+		s.Name = name
+	}
+	return idx.Sources, nil
+}
+
+func saveSources(paths *config.Paths, sources map[string]*Source) error {
+	if err := os.MkdirAll(paths.PluginSources, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(filepath.Join(paths.PluginSources, sourcesIndexFile))
+	if err != nil {
+		return err
+	}
+
+	if err := toml.NewEncoder(f).Encode(sourceIndex{Sources: sources}); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// パスまたは git URL からプラグイン定義を取得して追加する
+// 取得した定義は全て検証され、問題があれば何も追加しない
+func AddSource(paths *config.Paths, name, location, ref string) (*Source, error) {
+	if !isValidSourceName(name) {
+		return nil, fmt.Errorf("不正なソース名: %q (英数字, '-', '_' のみ使用可)", name)
+	}
+
+	sources, err := LoadSources(paths)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := sources[name]; ok {
+		return nil, fmt.Errorf("ソース %s は既に追加されています ('arsenal plugin update %s' で更新)", name, name)
+	}
+
+	kind, location, err := detectSourceKind(location)
+	if err != nil {
+		return nil, err
+	}
+	if kind == SourceKindLocal && ref != "" {
+		return nil, fmt.Errorf("--ref は git リポジトリでのみ使用できます")
+	}
+
+	src := &Source{Name: name, Kind: kind, Location: location, Ref: ref}
+	if err := installSource(paths, src); err != nil {
+		return nil, err
+	}
+
+	sources[name] = src
+	if err := saveSources(paths, sources); err != nil {
+		return nil, fmt.Errorf("ソース一覧の保存エラー: %w", err)
+	}
+	return src, nil
+}
+
+// 記録された取得元からプラグイン定義を取得し直す
+// 戻り値は更新前のリビジョンと更新後のソース
+func UpdateSource(paths *config.Paths, name string) (string, *Source, error) {
+	sources, err := LoadSources(paths)
+	if err != nil {
+		return "", nil, err
+	}
+
+	src, ok := sources[name]
+	if !ok {
+		return "", nil, fmt.Errorf("不明なプラグインソース: %s ('arsenal plugin list' で確認)", name)
+	}
+
+	oldRevision := src.Revision
+	if err := installSource(paths, src); err != nil {
+		return "", nil, err
+	}
+
+	if err := saveSources(paths, sources); err != nil {
+		return "", nil, fmt.Errorf("ソース一覧の保存エラー: %w", err)
+	}
+	return oldRevision, src, nil
+}
+
+// プラグインソースと取得済みの定義を削除する
+func RemoveSource(paths *config.Paths, name string) error {
+	sources, err := LoadSources(paths)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := sources[name]; !ok {
+		return fmt.Errorf("不明なプラグインソース: %s ('arsenal plugin list' で確認)", name)
+	}
+
+	if err := os.RemoveAll(filepath.Join(paths.PluginSources, name)); err != nil {
+		return fmt.Errorf("削除エラー: %w", err)
+	}
+
+	delete(sources, name)
+	return saveSources(paths, sources)
+}
+
+// 一時ディレクトリに取得・検証してから、既存の定義と入れ替える
+func installSource(paths *config.Paths, src *Source) error {
+	if err := os.MkdirAll(paths.PluginSources, 0755); err != nil {
+		return err
+	}
+
+	tmpDir, err := os.MkdirTemp(paths.PluginSources, "."+src.Name+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmpDir) }()
+
+	fetchDir := filepath.Join(tmpDir, "src")
+	var revision string
+	switch src.Kind {
+	case SourceKindGit:
+		revision, err = fetchGit(src.Location, src.Ref, fetchDir)
+	case SourceKindLocal:
+		revision, err = fetchLocal(src.Location, fetchDir)
+	default:
+		err = fmt.Errorf("不明なソース種別: %s", src.Kind)
+	}
+	if err != nil {
+		return err
+	}
+
+	plugins, err := validateSourceDir(fetchDir)
+	if err != nil {
+		return err
+	}
+
+	// 検証済みの定義を有効化
+	dest := filepath.Join(paths.PluginSources, src.Name)
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.Rename(fetchDir, dest); err != nil {
+		return err
+	}
+
+	src.Revision = revision
+	src.Plugins = plugins
+	src.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	return nil
+}
+
+// 取得したディレクトリ内の全プラグイン定義を読み込んで検証する
+func validateSourceDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	var problems []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".toml") {
+			continue
+		}
+
+		p, err := decodePluginFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", entry.Name(), err))
+			continue
+		}
+		for _, verr := range p.Validate() {
+			problems = append(problems, fmt.Sprintf("%s: %v", entry.Name(), verr))
+		}
+		if p.Name != "" {
+			names = append(names, p.Name)
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("プラグイン定義の検証に失敗しました:\n  %s", strings.Join(problems, "\n  "))
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("プラグイン定義 (*.toml) が見つかりません")
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// 取得元の種類を判定し、ローカルパスは絶対パスにして返す
+func detectSourceKind(location string) (string, string, error) {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "file://", "git@"} {
+		if strings.HasPrefix(location, prefix) {
+			return SourceKindGit, location, nil
+		}
+	}
+
+	abs, err := filepath.Abs(location)
+	if err != nil {
+		return "", "", err
+	}
+
+	info, err := os.Stat(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", "", fmt.Errorf("取得元が見つかりません: %s", location)
+		}
+		return "", "", err
+	}
+
+	if info.IsDir() && isGitRepository(abs) {
+		return SourceKindGit, abs, nil
+	}
+	return SourceKindLocal, abs, nil
+}
+
+// 通常のリポジトリ (.git を含む) またはベアリポジトリか判定する
+func isGitRepository(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		return true
+	}
+	_, headErr := os.Stat(filepath.Join(dir, "HEAD"))
+	_, objErr := os.Stat(filepath.Join(dir, "objects"))
+	return headErr == nil && objErr == nil
+}
+
+// git リポジトリを clone してコミットハッシュを返す
+func fetchGit(url, ref, dest string) (string, error) {
+	args := []string{"clone", "--quiet"}
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	args = append(args, url, dest)
+
+	if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git clone エラー: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	out, err := exec.Command("git", "-C", dest, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return "", fmt.Errorf("git rev-parse エラー: %w", err)
+	}
+
+	// 定義の読み込み時に不要なため .git は削除する
+	if err := os.RemoveAll(filepath.Join(dest, ".git")); err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// ローカルのファイルまたはディレクトリから TOML をコピーし、内容のハッシュを返す
+func fetchLocal(src, dest string) (string, error) {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(src)
+	if err != nil {
+		return "", err
+	}
+
+	var files []string
+	if info.IsDir() {
+		entries, err := os.ReadDir(src)
+		if err != nil {
+			return "", err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".toml") {
+				files = append(files, filepath.Join(src, entry.Name()))
+			}
+		}
+	} else {
+		files = []string{src}
+	}
+	sort.Strings(files)
+
+	h := sha256.New()
+	for _, file := range files {
+		if err := copyFileWithHash(file, filepath.Join(dest, filepath.Base(file)), h); err != nil {
+			return "", err
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+func copyFileWithHash(src, dest string, h io.Writer) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(io.MultiWriter(out, h), in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func isValidSourceName(name string) bool {
+	return sourceNamePattern.MatchString(name)
+}
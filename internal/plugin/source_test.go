@@ -0,0 +1,208 @@
+package plugin
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+)
+
+// テスト用のプラグイン定義
+const testSourcePlugin = `name = "terraform"
+display_name = "Terraform"
+download_url = "https://example.com/terraform_{{version}}_{{os}}_{{arch}}.zip"
+`
+
+// テスト用の Paths を作成するヘルパー関数
+func newSourceTestPaths(t *testing.T) *config.Paths {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	return &config.Paths{
+		Plugins:       filepath.Join(tmpDir, "plugins"),
+		PluginSources: filepath.Join(tmpDir, "plugin-sources"),
+	}
+}
+
+// ファイルを書き込むヘルパー関数
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+}
+
+// ローカルディレクトリからの追加・更新・削除をテストする
+func TestSourceLocalLifecycle(t *testing.T) {
+	paths := newSourceTestPaths(t)
+	srcDir := filepath.Join(t.TempDir(), "team-plugins")
+	writeFile(t, filepath.Join(srcDir, "terraform.toml"), testSourcePlugin)
+
+	src, err := AddSource(paths, "team", srcDir, "")
+	if err != nil {
+		t.Fatalf("AddSource() エラー: %v", err)
+	}
+	if src.Kind != SourceKindLocal {
+		t.Errorf("Kind = %q, want %q", src.Kind, SourceKindLocal)
+	}
+	if len(src.Plugins) != 1 || src.Plugins[0] != "terraform" {
+		t.Errorf("Plugins = %v, want [terraform]", src.Plugins)
+	}
+
+	// 追加したプラグインがレジストリから利用できるか確認
+	registry, err := NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("NewRegistry() エラー: %v", err)
+	}
+	if _, err := registry.Get("terraform"); err != nil {
+		t.Errorf("terraform プラグインが読み込まれていません: %v", err)
+	}
+
+	// 同名のソースは追加できない
+	if _, err := AddSource(paths, "team", srcDir, ""); err == nil {
+		t.Error("同名のソースを追加できてしまいました")
+	}
+
+	// 取得元を変更して更新
+	writeFile(t, filepath.Join(srcDir, "kubectl.toml"), `name = "kubectl"
+download_url = "https://example.com/kubectl-{{version}}"
+`)
+	oldRevision, updated, err := UpdateSource(paths, "team")
+	if err != nil {
+		t.Fatalf("UpdateSource() エラー: %v", err)
+	}
+	if oldRevision == updated.Revision {
+		t.Error("内容が変わったのにリビジョンが変わっていません")
+	}
+	if len(updated.Plugins) != 2 {
+		t.Errorf("Plugins = %v, want 2 件", updated.Plugins)
+	}
+
+	// 記録が永続化されているか確認
+	sources, err := LoadSources(paths)
+	if err != nil {
+		t.Fatalf("LoadSources() エラー: %v", err)
+	}
+	if sources["team"] == nil || sources["team"].Revision != updated.Revision {
+		t.Errorf("記録されたソース = %+v", sources["team"])
+	}
+
+	if err := RemoveSource(paths, "team"); err != nil {
+		t.Fatalf("RemoveSource() エラー: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(paths.PluginSources, "team")); !os.IsNotExist(err) {
+		t.Error("ソースのディレクトリが削除されていません")
+	}
+	if err := RemoveSource(paths, "team"); err == nil {
+		t.Error("存在しないソースの削除でエラーが返されませんでした")
+	}
+}
+
+// 不正な定義を含むソースが追加されないかテストする
+func TestAddSourceInvalid(t *testing.T) {
+	paths := newSourceTestPaths(t)
+	srcDir := filepath.Join(t.TempDir(), "broken")
+	writeFile(t, filepath.Join(srcDir, "broken.toml"), `display_name = "No Name"`)
+
+	if _, err := AddSource(paths, "broken", srcDir, ""); err == nil {
+		t.Fatal("不正な定義でエラーが返されませんでした")
+	}
+
+	sources, err := LoadSources(paths)
+	if err != nil {
+		t.Fatalf("LoadSources() エラー: %v", err)
+	}
+	if len(sources) != 0 {
+		t.Errorf("検証に失敗したソースが記録されています: %v", sources)
+	}
+	if _, err := os.Stat(filepath.Join(paths.PluginSources, "broken")); !os.IsNotExist(err) {
+		t.Error("検証に失敗したソースが有効化されています")
+	}
+}
+
+// 不正なソース名が拒否されるかテストする
+func TestAddSourceInvalidName(t *testing.T) {
+	paths := newSourceTestPaths(t)
+
+	for _, name := range []string{"", ".hidden", "a/b", "team plugins"} {
+		if _, err := AddSource(paths, name, t.TempDir(), ""); err == nil {
+			t.Errorf("不正な名前 %q でエラーが返されませんでした", name)
+		}
+	}
+}
+
+// ローカルのベアリポジトリからの追加と更新をテストする
+func TestSourceGitLifecycle(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git が見つかりません")
+	}
+
+	tmpDir := t.TempDir()
+	bareDir := filepath.Join(tmpDir, "plugins.git")
+	workDir := filepath.Join(tmpDir, "work")
+
+	git := func(dir string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+		)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v エラー: %v: %s", args, err, out)
+		}
+	}
+
+	git(tmpDir, "init", "--quiet", "--bare", bareDir)
+	git(tmpDir, "clone", "--quiet", bareDir, workDir)
+	writeFile(t, filepath.Join(workDir, "terraform.toml"), testSourcePlugin)
+	git(workDir, "add", ".")
+	git(workDir, "commit", "--quiet", "-m", "add terraform")
+	git(workDir, "push", "--quiet", "origin", "HEAD")
+
+	paths := newSourceTestPaths(t)
+	src, err := AddSource(paths, "team", bareDir, "")
+	if err != nil {
+		t.Fatalf("AddSource() エラー: %v", err)
+	}
+	if src.Kind != SourceKindGit {
+		t.Errorf("Kind = %q, want %q", src.Kind, SourceKindGit)
+	}
+	if len(src.Revision) != 40 {
+		t.Errorf("Revision = %q, コミットハッシュではありません", src.Revision)
+	}
+	if _, err := os.Stat(filepath.Join(paths.PluginSources, "team", ".git")); !os.IsNotExist(err) {
+		t.Error(".git が残っています")
+	}
+
+	// 新しいコミットを push して更新
+	writeFile(t, filepath.Join(workDir, "kubectl.toml"), `name = "kubectl"
+download_url = "https://example.com/kubectl-{{version}}"
+`)
+	git(workDir, "add", ".")
+	git(workDir, "commit", "--quiet", "-m", "add kubectl")
+	git(workDir, "push", "--quiet", "origin", "HEAD")
+
+	oldRevision, updated, err := UpdateSource(paths, "team")
+	if err != nil {
+		t.Fatalf("UpdateSource() エラー: %v", err)
+	}
+	if oldRevision == updated.Revision {
+		t.Error("新しいコミットが取得されていません")
+	}
+
+	registry, err := NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("NewRegistry() エラー: %v", err)
+	}
+	if _, err := registry.Get("kubectl"); err != nil {
+		t.Errorf("kubectl プラグインが読み込まれていません: %v", err)
+	}
+}
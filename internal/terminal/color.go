@@ -2,6 +2,7 @@ package terminal
 
 import (
 	"fmt"
+	"io"
 	"os"
 )
 
@@ -70,7 +71,12 @@ func Cyan(text string) string {
 
 // PrintSuccess は成功メッセージを表示する
 func PrintSuccess(format string, args ...interface{}) {
-	fmt.Printf(Green("✅ "+format)+"\n", args...)
+	FprintSuccess(os.Stdout, format, args...)
+}
+
+// FprintSuccess は成功メッセージを w に書き出す
+func FprintSuccess(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintf(w, Green("✅ "+format)+"\n", args...)
 }
 
 // PrintError はエラーメッセージを表示する
@@ -80,12 +86,22 @@ func PrintError(format string, args ...interface{}) {
 
 // PrintWarning は警告メッセージを表示する
 func PrintWarning(format string, args ...interface{}) {
-	fmt.Printf(Yellow("⚠️  "+format)+"\n", args...)
+	FprintWarning(os.Stdout, format, args...)
+}
+
+// FprintWarning は警告メッセージを w に書き出す
+func FprintWarning(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintf(w, Yellow("⚠️  "+format)+"\n", args...)
 }
 
 // PrintInfo は情報メッセージを表示する
 func PrintInfo(format string, args ...interface{}) {
-	fmt.Printf(Blue("📦 "+format)+"\n", args...)
+	FprintInfo(os.Stdout, format, args...)
+}
+
+// FprintInfo は情報メッセージを w に書き出す
+func FprintInfo(w io.Writer, format string, args ...interface{}) {
+	fmt.Fprintf(w, Blue("📦 "+format)+"\n", args...)
 }
 
 // PrintfBlue は青色でフォーマット出力する
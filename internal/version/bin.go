@@ -0,0 +1,210 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/arsenal/internal/terminal"
+)
+
+// ツールが公開する実行ファイル 1 件を表す
+type Executable struct {
+	Name string // 実行ファイル名（例: npm）
+	Path string // 実体の絶対パス
+}
+
+// アクティブバージョンの bin ディレクトリを current 経由のパスで返す
+// 例: ~/.arsenal/current/node/bin
+func (m *Manager) CurrentBinDirs(toolName string) []string {
+	p, err := m.registry.Get(toolName)
+	if err != nil {
+		return nil
+	}
+
+	binPaths := p.ResolveBinPaths()
+	dirs := make([]string, 0, len(binPaths))
+	for _, bp := range binPaths {
+		dirs = append(dirs, m.paths.ToolBinPath(toolName, bp))
+	}
+	return dirs
+}
+
+// 指定バージョンの bin ディレクトリを versions 配下のパスで返す
+// 例: ~/.arsenal/versions/node/20.10.0/bin
+func (m *Manager) VersionBinDirs(toolName, version string) []string {
+	p, err := m.registry.Get(toolName)
+	if err != nil {
+		return nil
+	}
+
+	versionDir := m.paths.ToolVersionPath(toolName, version)
+	binPaths := p.ResolveBinPaths()
+	dirs := make([]string, 0, len(binPaths))
+	for _, bp := range binPaths {
+		dirs = append(dirs, filepath.Join(versionDir, bp))
+	}
+	return dirs
+}
+
+// 指定バージョンが公開する実行ファイルを名前順で返す
+// 同名のファイルが複数の bin ディレクトリにある場合は先のディレクトリを優先し、
+// プラグインの executables 許可リストに含まれないものは除外する
+func (m *Manager) Executables(toolName, version string) ([]Executable, error) {
+	p, err := m.registry.Get(toolName)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var result []Executable
+
+	for _, dir := range m.VersionBinDirs(toolName, version) {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		for _, e := range entries {
+			name := e.Name()
+			if seen[name] || !p.ExposesExecutable(name) {
+				continue
+			}
+
+			path := filepath.Join(dir, name)
+			if !isExecutable(path) {
+				continue
+			}
+
+			seen[name] = true
+			result = append(result, Executable{Name: name, Path: path})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// 指定バージョンが公開する実行ファイルだけを含む bin ディレクトリを返す
+// executables 許可リストがなければ versions 配下の bin ディレクトリをそのまま返し、
+// あれば ~/.arsenal/links/<tool>/<version> を許可された実行ファイルへの symlink で作り直して返す
+func (m *Manager) ExposedBinDirs(toolName, version string) ([]string, error) {
+	p, err := m.registry.Get(toolName)
+	if err != nil {
+		return nil, err
+	}
+	if len(p.Executables) == 0 {
+		return m.VersionBinDirs(toolName, version), nil
+	}
+	if m.paths.Links == "" {
+		return nil, nil
+	}
+
+	exes, err := m.Executables(toolName, version)
+	if err != nil {
+		return nil, err
+	}
+
+	linkDir := m.paths.ToolLinksPath(toolName, version)
+	if err := os.RemoveAll(linkDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(linkDir, 0755); err != nil {
+		return nil, err
+	}
+	for _, exe := range exes {
+		if err := os.Symlink(exe.Path, filepath.Join(linkDir, exe.Name)); err != nil {
+			return nil, err
+		}
+	}
+	return []string{linkDir}, nil
+}
+
+// ~/.arsenal/bin をアクティブな全ツールの実行ファイルへの symlink で作り直す
+// 複数のツールが同名の実行ファイルを公開する場合はツール名順で先のものを優先する
+func (m *Manager) RebuildBin() error {
+	binDir := m.paths.Bin
+	if binDir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return err
+	}
+
+	// 既存の symlink を削除（ユーザーが置いた通常ファイルは残す）
+	entries, err := os.ReadDir(binDir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Type()&os.ModeSymlink == 0 {
+			continue
+		}
+		if err := os.Remove(filepath.Join(binDir, e.Name())); err != nil {
+			return err
+		}
+	}
+
+	currentAll, err := m.CurrentAll()
+	if err != nil {
+		return err
+	}
+
+	tools := make([]string, 0, len(currentAll))
+	for tool := range currentAll {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	owners := make(map[string]string)
+	for _, tool := range tools {
+		exes, err := m.Executables(tool, currentAll[tool])
+		if err != nil {
+			// プラグインが削除されたツールなどはスキップ
+			continue
+		}
+
+		for _, exe := range exes {
+			if owner, ok := owners[exe.Name]; ok {
+				terminal.PrintWarning("%s は %s と %s の両方にあります (%s を使用)", exe.Name, owner, tool, owner)
+				continue
+			}
+
+			link := filepath.Join(binDir, exe.Name)
+			if _, err := os.Lstat(link); err == nil {
+				// 同名の通常ファイルがある場合は上書きしない
+				continue
+			}
+			if err := os.Symlink(exe.Path, link); err != nil {
+				return err
+			}
+			owners[exe.Name] = tool
+		}
+	}
+
+	return nil
+}
+
+// 実行可能な通常ファイルか判定する（symlink は辿る）
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	if runtime.GOOS == "windows" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".exe", ".cmd", ".bat", ".ps1":
+			return true
+		}
+		return false
+	}
+
+	return info.Mode()&0111 != 0
+}
@@ -0,0 +1,245 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// 複数 bin ディレクトリ用のテストプラグイン
+const multiBinPlugin = `name = "testrust"
+display_name = "Test Rust"
+bin_paths = ["cargo/bin", "rustc/bin"]
+executables = ["cargo", "rustc"]
+`
+
+// 実行ファイルを作成するヘルパー関数
+func writeExecutable(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("実行ファイル作成エラー: %v", err)
+	}
+}
+
+// bin_paths の各ディレクトリが返されるかテストする
+func TestManagerBinDirs(t *testing.T) {
+	m, paths := newTestManager(t, map[string]string{"testrust.toml": multiBinPlugin})
+
+	versionDirs := m.VersionBinDirs("testrust", "1.75.0")
+	wantVersion := []string{
+		filepath.Join(paths.Versions, "testrust", "1.75.0", "cargo", "bin"),
+		filepath.Join(paths.Versions, "testrust", "1.75.0", "rustc", "bin"),
+	}
+	if len(versionDirs) != len(wantVersion) {
+		t.Fatalf("VersionBinDirs() = %v, want %v", versionDirs, wantVersion)
+	}
+	for i := range wantVersion {
+		if versionDirs[i] != wantVersion[i] {
+			t.Errorf("VersionBinDirs()[%d] = %q, want %q", i, versionDirs[i], wantVersion[i])
+		}
+	}
+
+	currentDirs := m.CurrentBinDirs("testrust")
+	if len(currentDirs) != 2 || currentDirs[0] != filepath.Join(paths.Current, "testrust", "cargo", "bin") {
+		t.Errorf("CurrentBinDirs() = %v", currentDirs)
+	}
+}
+
+// executables 許可リストに従って実行ファイルが列挙されるかテストする
+func TestManagerExecutables(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, map[string]string{"testrust.toml": multiBinPlugin})
+
+	versionDir := paths.ToolVersionPath("testrust", "1.75.0")
+	writeExecutable(t, filepath.Join(versionDir, "cargo", "bin", "cargo"))
+	writeExecutable(t, filepath.Join(versionDir, "cargo", "bin", "cargo-helper"))
+	writeExecutable(t, filepath.Join(versionDir, "rustc", "bin", "rustc"))
+
+	// 実行ビットのないファイルは除外される
+	if err := os.WriteFile(filepath.Join(versionDir, "rustc", "bin", "README"), []byte("doc"), 0644); err != nil {
+		t.Fatalf("ファイル作成エラー: %v", err)
+	}
+
+	exes, err := m.Executables("testrust", "1.75.0")
+	if err != nil {
+		t.Fatalf("Executables() エラー: %v", err)
+	}
+
+	if len(exes) != 2 {
+		t.Fatalf("実行ファイル数 = %d, want 2: %v", len(exes), exes)
+	}
+	if exes[0].Name != "cargo" || exes[1].Name != "rustc" {
+		t.Errorf("Executables() = %v", exes)
+	}
+	if exes[1].Path != filepath.Join(versionDir, "rustc", "bin", "rustc") {
+		t.Errorf("rustc のパス = %q", exes[1].Path)
+	}
+}
+
+// 許可リストにない実行ファイルが PATH に追加するディレクトリから除外されるかテストする
+func TestManagerExposedBinDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink と実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, map[string]string{
+		"testrust.toml": multiBinPlugin,
+		"testnode.toml": "name = \"testnode\"\n",
+	})
+
+	rustDir := paths.ToolVersionPath("testrust", "1.75.0")
+	writeExecutable(t, filepath.Join(rustDir, "cargo", "bin", "cargo"))
+	writeExecutable(t, filepath.Join(rustDir, "cargo", "bin", "cargo-helper"))
+	writeExecutable(t, filepath.Join(rustDir, "rustc", "bin", "rustc"))
+
+	// 前回の結果に残った実行ファイルは作り直しで消える
+	linkDir := paths.ToolLinksPath("testrust", "1.75.0")
+	writeExecutable(t, filepath.Join(linkDir, "stale"))
+
+	r := Resolution{Tool: "testrust", Version: "1.75.0", Source: SourceToolVersions, Installed: true}
+	dirs := m.ResolvedBinDirs([]Resolution{r})
+	if len(dirs) != 1 || dirs[0] != linkDir {
+		t.Fatalf("ResolvedBinDirs() = %v, want [%s]", dirs, linkDir)
+	}
+
+	entries, err := os.ReadDir(linkDir)
+	if err != nil {
+		t.Fatalf("ディレクトリ読み込みエラー: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if len(names) != 2 || names[0] != "cargo" || names[1] != "rustc" {
+		t.Errorf("公開される実行ファイル = %v, want [cargo rustc]", names)
+	}
+	if target, err := os.Readlink(filepath.Join(linkDir, "cargo")); err != nil || target != filepath.Join(rustDir, "cargo", "bin", "cargo") {
+		t.Errorf("cargo のリンク先 = %q, %v", target, err)
+	}
+
+	// 許可リストがなければ versions 配下の bin ディレクトリをそのまま使う
+	nodeDirs, err := m.ExposedBinDirs("testnode", "20.10.0")
+	if err != nil {
+		t.Fatalf("ExposedBinDirs() エラー: %v", err)
+	}
+	if len(nodeDirs) != 1 || nodeDirs[0] != filepath.Join(paths.ToolVersionPath("testnode", "20.10.0"), "bin") {
+		t.Errorf("ExposedBinDirs() = %v", nodeDirs)
+	}
+}
+
+// 許可リストの実行ファイルが欠けている場合に doctor が警告するかテストする
+func TestCheckToolBins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, map[string]string{"testrust.toml": multiBinPlugin})
+
+	versionDir := paths.ToolVersionPath("testrust", "1.75.0")
+	writeExecutable(t, filepath.Join(versionDir, "cargo", "bin", "cargo"))
+	if err := os.MkdirAll(filepath.Join(versionDir, "rustc", "bin"), 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	result := m.checkToolBins("testrust", "1.75.0")
+	if result.Status != StatusWarn {
+		t.Errorf("Status = %v, want StatusWarn (%s)", result.Status, result.Message)
+	}
+
+	writeExecutable(t, filepath.Join(versionDir, "rustc", "bin", "rustc"))
+
+	result = m.checkToolBins("testrust", "1.75.0")
+	if result.Status != StatusOK {
+		t.Errorf("Status = %v, want StatusOK (%s)", result.Status, result.Message)
+	}
+}
+
+// Use / Uninstall で ~/.arsenal/bin が再構築されるかテストする
+func TestRebuildBin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlink と実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, map[string]string{
+		"testrust.toml": multiBinPlugin,
+		"testnode.toml": `name = "testnode"
+download_url = "https://example.com/node-{{version}}.tar.gz"
+`,
+	})
+
+	rustDir := paths.ToolVersionPath("testrust", "1.75.0")
+	writeExecutable(t, filepath.Join(rustDir, "cargo", "bin", "cargo"))
+	writeExecutable(t, filepath.Join(rustDir, "cargo", "bin", "cargo-helper"))
+	writeExecutable(t, filepath.Join(rustDir, "rustc", "bin", "rustc"))
+
+	nodeDir := paths.ToolVersionPath("testnode", "20.10.0")
+	writeExecutable(t, filepath.Join(nodeDir, "bin", "node"))
+	writeExecutable(t, filepath.Join(nodeDir, "bin", "npm"))
+
+	if err := m.Use("testrust", "1.75.0"); err != nil {
+		t.Fatalf("Use(testrust) エラー: %v", err)
+	}
+	if err := m.Use("testnode", "20.10.0"); err != nil {
+		t.Fatalf("Use(testnode) エラー: %v", err)
+	}
+
+	// 許可リスト外の cargo-helper 以外がリンクされる
+	want := map[string]string{
+		"cargo": filepath.Join(rustDir, "cargo", "bin", "cargo"),
+		"rustc": filepath.Join(rustDir, "rustc", "bin", "rustc"),
+		"node":  filepath.Join(nodeDir, "bin", "node"),
+		"npm":   filepath.Join(nodeDir, "bin", "npm"),
+	}
+	entries, err := os.ReadDir(paths.Bin)
+	if err != nil {
+		t.Fatalf("bin ディレクトリ読み込みエラー: %v", err)
+	}
+	if len(entries) != len(want) {
+		t.Errorf("リンク数 = %d, want %d", len(entries), len(want))
+	}
+	for name, target := range want {
+		got, err := os.Readlink(filepath.Join(paths.Bin, name))
+		if err != nil {
+			t.Errorf("%s のリンクがありません: %v", name, err)
+			continue
+		}
+		if got != target {
+			t.Errorf("%s -> %q, want %q", name, got, target)
+		}
+	}
+
+	// アンインストールするとリンクも削除される
+	if err := m.Uninstall("testnode", "20.10.0"); err != nil {
+		t.Fatalf("Uninstall() エラー: %v", err)
+	}
+	if _, err := os.Lstat(filepath.Join(paths.Bin, "node")); !os.IsNotExist(err) {
+		t.Error("アンインストール後も node のリンクが残っています")
+	}
+	if _, err := os.Lstat(filepath.Join(paths.Bin, "cargo")); err != nil {
+		t.Error("他のツールのリンクが削除されました")
+	}
+}
+
+// checkPATH が PATH のエントリを正確に判定するかテストする
+func TestCheckPATH(t *testing.T) {
+	m, paths := newTestManager(t, nil)
+
+	t.Setenv("PATH", paths.Bin+string(os.PathListSeparator)+"/usr/bin")
+	if result := m.checkPATH(); result.Status != StatusOK {
+		t.Errorf("Status = %v, want StatusOK (%s)", result.Status, result.Message)
+	}
+
+	// 部分一致では OK にならない
+	t.Setenv("PATH", paths.Bin+"-old"+string(os.PathListSeparator)+paths.Current+"/*/bin")
+	if result := m.checkPATH(); result.Status != StatusWarn {
+		t.Errorf("Status = %v, want StatusWarn (%s)", result.Status, result.Message)
+	}
+}
@@ -0,0 +1,317 @@
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// プロジェクトのファイルから検出したツールのバージョン要件
+type DetectedTool struct {
+	Tool       string
+	Constraint string // ファイルに書かれた要件（例: >=18）
+	Source     string // 検出元（例: package.json の engines.node）
+
+	req versionRequirement
+}
+
+// バージョン要件（|| で区切られた候補のいずれかに一致するバージョンが一致する）
+type versionRequirement []versionRange
+
+// || で区切られた 1 つの候補（全ての条件を満たすバージョンが一致する）
+type versionRange struct {
+	spec         string   // 前方一致するバージョン指定（空なら制限なし）
+	min          string   // 以上（空なら制限なし）
+	minExclusive bool     // min に前方一致するバージョンを除く（>）
+	max          string   // 未満（空なら制限なし）
+	maxInclusive bool     // max に前方一致するバージョンも含める（<=）
+	excludes     []string // 前方一致するバージョンを除く（!=）
+}
+
+// 要件として扱えるバージョン（数字とドットのみ。プレリリースは除く）
+var plainVersionPattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// 演算子とバージョンの間の空白（">= 18" を ">=18" にする）
+var constraintOperatorSpace = regexp.MustCompile(`([<>=~^!]+)\s+`)
+
+// バージョンが要件に一致するか判定する
+func (d DetectedTool) Matches(version string) bool {
+	if !plainVersionPattern.MatchString(version) {
+		return false
+	}
+	for _, r := range d.req {
+		if r.matches(version) {
+			return true
+		}
+	}
+	return false
+}
+
+// バージョンが候補の全ての条件を満たすか判定する
+func (r versionRange) matches(version string) bool {
+	if r.spec != "" && !MatchesVersionSpec(r.spec, version) {
+		return false
+	}
+	if r.min != "" {
+		if compareVersions(version, r.min) < 0 {
+			return false
+		}
+		if r.minExclusive && MatchesVersionSpec(r.min, version) {
+			return false
+		}
+	}
+	if r.max != "" && compareVersions(version, r.max) >= 0 {
+		if !r.maxInclusive || !MatchesVersionSpec(r.max, version) {
+			return false
+		}
+	}
+	for _, e := range r.excludes {
+		if MatchesVersionSpec(e, version) {
+			return false
+		}
+	}
+	return true
+}
+
+// dir のファイルからツールのバージョン要件を検出する
+//
+//	package.json        engines.node
+//	go.mod              toolchain 行、なければ go 行
+//	pyproject.toml      project.requires-python、なければ tool.poetry.dependencies.python
+//	.terraform-version  1 行目のバージョン
+//
+// 解釈できない要件は警告として返す
+func DetectTools(dir string) ([]DetectedTool, []string, error) {
+	var detected []DetectedTool
+	var warnings []string
+
+	add := func(tool, constraint, source string) {
+		req, err := parseVersionConstraint(constraint)
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s の %q を解釈できません: %v", source, constraint, err))
+			return
+		}
+		detected = append(detected, DetectedTool{Tool: tool, Constraint: constraint, Source: source, req: req})
+	}
+
+	readFile := func(name string) (string, bool, error) {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", false, nil
+			}
+			return "", false, fmt.Errorf("%s 読み込みエラー: %w", name, err)
+		}
+		return string(data), true, nil
+	}
+
+	// package.json
+	if content, ok, err := readFile("package.json"); err != nil {
+		return nil, nil, err
+	} else if ok {
+		var pkg struct {
+			Engines map[string]string `json:"engines"`
+		}
+		if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+			return nil, nil, fmt.Errorf("package.json の解析エラー: %w", err)
+		}
+		if c := pkg.Engines["node"]; c != "" {
+			add("node", c, "package.json の engines.node")
+		}
+	}
+
+	// go.mod
+	if content, ok, err := readFile("go.mod"); err != nil {
+		return nil, nil, err
+	} else if ok {
+		if versions := parseGoModToolchain(content); len(versions) > 0 {
+			add("go", versions[0], "go.mod の toolchain")
+		} else if v := parseGoModGoDirective(content); v != "" {
+			add("go", v, "go.mod の go")
+		}
+	}
+
+	// pyproject.toml
+	if content, ok, err := readFile("pyproject.toml"); err != nil {
+		return nil, nil, err
+	} else if ok {
+		var py struct {
+			Project struct {
+				RequiresPython string `toml:"requires-python"`
+			} `toml:"project"`
+			Tool struct {
+				Poetry struct {
+					Dependencies map[string]interface{} `toml:"dependencies"`
+				} `toml:"poetry"`
+			} `toml:"tool"`
+		}
+		if _, err := toml.Decode(content, &py); err != nil {
+			return nil, nil, fmt.Errorf("pyproject.toml の解析エラー: %w", err)
+		}
+		if c := py.Project.RequiresPython; c != "" {
+			add("python", c, "pyproject.toml の requires-python")
+		} else if c, ok := py.Tool.Poetry.Dependencies["python"].(string); ok && c != "" {
+			add("python", c, "pyproject.toml の tool.poetry.dependencies.python")
+		}
+	}
+
+	// .terraform-version
+	if content, ok, err := readFile(".terraform-version"); err != nil {
+		return nil, nil, err
+	} else if ok {
+		if versions := parsePlainVersionFile(content); len(versions) > 0 {
+			add("terraform", versions[0], ".terraform-version")
+		} else {
+			warnings = append(warnings, ".terraform-version からバージョンを読めません")
+		}
+	}
+
+	return detected, warnings, nil
+}
+
+// go.mod の go 行からバージョンを読む（go 行がなければ空）
+func parseGoModGoDirective(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "go" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// npm / PEP 440 / Poetry のバージョン要件を解釈する
+// || で区切られた候補はいずれかに一致すればよく、カンマや空白で区切られた条件は全て満たすものとする
+//
+//	20.10.0, =20.10.0, ==3.12.*, 20.x   前方一致
+//	^20.10.0                            20.x かつ 20.10.0 以上（0.x はマイナーまで固定）
+//	~20.10.0                            20.10.x かつ 20.10.0 以上
+//	~=3.11                              3.x かつ 3.11 以上
+//	>=18, >18                           18 以上、18.x より後（npm と同じく 18.0.0 は含まない）
+//	<3.13, <=3.12                       3.13 未満、3.12.x 以下
+//	!=3.12.0, !=3.12.*                  3.12.0、3.12.x を除く
+func parseVersionConstraint(constraint string) (versionRequirement, error) {
+	var req versionRequirement
+	for _, alternative := range strings.Split(constraint, "||") {
+		r, err := parseVersionRange(alternative)
+		if err != nil {
+			return nil, err
+		}
+		req = append(req, r)
+	}
+	return req, nil
+}
+
+// || で区切られた 1 つの候補を解釈する
+func parseVersionRange(alternative string) (versionRange, error) {
+	var r versionRange
+
+	c := constraintOperatorSpace.ReplaceAllString(strings.TrimSpace(alternative), "$1")
+	clauses := strings.FieldsFunc(c, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+	if len(clauses) == 0 {
+		return r, fmt.Errorf("要件が空です")
+	}
+
+	for _, clause := range clauses {
+		if clause == "*" || clause == "x" || clause == "latest" {
+			continue
+		}
+
+		op := clause[:len(clause)-len(strings.TrimLeft(clause, "<>=~^!"))]
+		v := strings.TrimPrefix(clause[len(op):], "v")
+		for _, wildcard := range []string{".*", ".x", ".X"} {
+			v = strings.TrimSuffix(v, wildcard)
+		}
+		if !plainVersionPattern.MatchString(v) {
+			return r, fmt.Errorf("バージョンではありません: %s", clause)
+		}
+		parts := strings.Split(v, ".")
+
+		switch op {
+		case "", "=", "==", "===":
+			r.spec = v
+		case "^":
+			r.spec = parts[0]
+			if parts[0] == "0" && len(parts) > 1 {
+				r.spec = parts[0] + "." + parts[1]
+			}
+			r.min = v
+		case "~":
+			r.spec = parts[0]
+			if len(parts) > 1 {
+				r.spec = parts[0] + "." + parts[1]
+			}
+			r.min = v
+		case "~=":
+			r.spec = v
+			if len(parts) > 1 {
+				r.spec = strings.Join(parts[:len(parts)-1], ".")
+			}
+			r.min = v
+		case ">=":
+			r.min = v
+			r.minExclusive = false
+		case ">":
+			r.min = v
+			r.minExclusive = true
+		case "<":
+			r.max = v
+			r.maxInclusive = false
+		case "<=":
+			r.max = v
+			r.maxInclusive = true
+		case "!=":
+			r.excludes = append(r.excludes, v)
+		default:
+			return r, fmt.Errorf("不明な演算子です: %s", op)
+		}
+	}
+	return r, nil
+}
+
+// 検出した要件に合う具体的なバージョンとその選び方を返す
+// 有効なバージョン（グローバルの current）が一致すればそれを、なければリモートの一覧から一致する最新、
+// 一覧を取得できなければ一致するインストール済みの最新を使う
+func (m *Manager) ProposeVersion(d DetectedTool) (string, string, error) {
+	if current, _ := m.Current(d.Tool); current != "" && d.Matches(current) {
+		return current, "有効なバージョン", nil
+	}
+
+	remote, remoteErr := m.ListRemote(d.Tool, 0)
+	if remoteErr == nil {
+		versions := make([]string, 0, len(remote))
+		for _, rv := range remote {
+			versions = append(versions, rv.Version)
+		}
+		if v := latestSatisfying(versions, d); v != "" {
+			return v, "リモートの最新", nil
+		}
+	}
+
+	if installed, err := m.List(d.Tool); err == nil {
+		if v := latestSatisfying(installed, d); v != "" {
+			return v, "インストール済み", nil
+		}
+	}
+
+	if remoteErr != nil {
+		return "", "", fmt.Errorf("%s に一致するバージョンがありません (リモートの一覧: %v)", d.Constraint, remoteErr)
+	}
+	return "", "", fmt.Errorf("%s に一致するバージョンがありません", d.Constraint)
+}
+
+// versions のうち要件に一致する最新のバージョンを返す
+func latestSatisfying(versions []string, d DetectedTool) string {
+	best := ""
+	for _, v := range versions {
+		if d.Matches(v) && (best == "" || compareVersions(v, best) > 0) {
+			best = v
+		}
+	}
+	return best
+}
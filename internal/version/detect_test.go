@@ -0,0 +1,153 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// バージョン要件の解釈をテストする
+func TestParseVersionConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		match      []string
+		noMatch    []string
+		wantErr    bool
+	}{
+		{constraint: "20.10.0", match: []string{"20.10.0"}, noMatch: []string{"20.10.1", "20.11.0"}},
+		{constraint: "20.x", match: []string{"20.0.0", "20.11.1"}, noMatch: []string{"21.0.0"}},
+		{constraint: "==3.12.*", match: []string{"3.12.1"}, noMatch: []string{"3.11.7"}},
+		{constraint: "^20.10.0", match: []string{"20.10.0", "20.11.0"}, noMatch: []string{"20.9.0", "21.0.0"}},
+		{constraint: "^0.8.1", match: []string{"0.8.3"}, noMatch: []string{"0.9.0"}},
+		{constraint: "~20.10.0", match: []string{"20.10.5"}, noMatch: []string{"20.11.0"}},
+		{constraint: "~=3.11", match: []string{"3.11.0", "3.13.1"}, noMatch: []string{"3.10.9", "4.0.0"}},
+		{constraint: ">=18", match: []string{"18.0.0", "22.1.0"}, noMatch: []string{"16.20.2"}},
+		{constraint: ">= 3.11, <3.13", match: []string{"3.12.1"}, noMatch: []string{"3.13.0", "3.10.1"}},
+		{constraint: "<=3.12", match: []string{"3.12.9"}, noMatch: []string{"3.13.0"}},
+		{constraint: ">18", match: []string{"19.0.0"}, noMatch: []string{"18.0.0", "18.19.0", "17.9.1"}},
+		{constraint: ">18.2.0", match: []string{"18.2.1", "19.0.0"}, noMatch: []string{"18.2.0", "18.1.9"}},
+		{constraint: ">=3.11, !=3.12.0", match: []string{"3.11.7", "3.12.1"}, noMatch: []string{"3.12.0", "3.10.9"}},
+		{constraint: "!=3.12.*", match: []string{"3.11.7", "3.13.0"}, noMatch: []string{"3.12.0", "3.12.5"}},
+		{constraint: "16 || 18 || 20", match: []string{"16.20.2", "18.19.0", "20.1.0"}, noMatch: []string{"17.0.0", "22.0.0"}},
+		{constraint: "^18.19.0 || >=20.5", match: []string{"18.20.0", "20.5.0"}, noMatch: []string{"19.0.0", "20.4.0"}},
+		{constraint: ">=18", noMatch: []string{"23.0.0-rc.1"}},
+		{constraint: "lts/iron", wantErr: true},
+		{constraint: "", wantErr: true},
+		{constraint: "18 || lts/iron", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			req, err := parseVersionConstraint(tt.constraint)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseVersionConstraint() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			d := DetectedTool{req: req}
+			for _, v := range tt.match {
+				if !d.Matches(v) {
+					t.Errorf("%s は %s に一致するはずです", v, tt.constraint)
+				}
+			}
+			for _, v := range tt.noMatch {
+				if d.Matches(v) {
+					t.Errorf("%s は %s に一致しないはずです", v, tt.constraint)
+				}
+			}
+		})
+	}
+}
+
+// プロジェクトのファイルからの検出をテストする
+func TestDetectTools(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"package.json":       `{"name": "app", "engines": {"node": ">=18", "npm": ">=9"}}`,
+		"go.mod":             "module example.com/app\n\ngo 1.22\n",
+		"pyproject.toml":     "[tool.poetry.dependencies]\npython = \"^3.11\"\n",
+		".terraform-version": "1.7.0\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("テストファイル作成エラー: %v", err)
+		}
+	}
+
+	detected, warnings, err := DetectTools(dir)
+	if err != nil {
+		t.Fatalf("DetectTools() エラー: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %v", warnings)
+	}
+
+	want := []struct{ tool, constraint, source string }{
+		{"node", ">=18", "package.json の engines.node"},
+		{"go", "1.22", "go.mod の go"},
+		{"python", "^3.11", "pyproject.toml の tool.poetry.dependencies.python"},
+		{"terraform", "1.7.0", ".terraform-version"},
+	}
+	if len(detected) != len(want) {
+		t.Fatalf("DetectTools() = %+v", detected)
+	}
+	for i, w := range want {
+		d := detected[i]
+		if d.Tool != w.tool || d.Constraint != w.constraint || d.Source != w.source {
+			t.Errorf("detected[%d] = %+v, want %+v", i, d, w)
+		}
+	}
+
+	// toolchain 行は go 行より優先
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module example.com/app\n\ngo 1.22\n\ntoolchain go1.22.1\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+	detected, _, _ = DetectTools(dir)
+	if detected[1].Constraint != "1.22.1" || detected[1].Source != "go.mod の toolchain" {
+		t.Errorf("detected[1] = %+v, want go.mod の toolchain 1.22.1", detected[1])
+	}
+}
+
+// 有効なバージョン、インストール済みのバージョンからの提案をテストする
+func TestManagerProposeVersion(t *testing.T) {
+	m, paths := newTestManager(t, map[string]string{"testpy.toml": `name = "testpy"`})
+
+	for _, v := range []string{"3.10.4", "3.11.7", "3.12.1"} {
+		if err := os.MkdirAll(paths.ToolVersionPath("testpy", v), 0755); err != nil {
+			t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+		}
+	}
+	if err := m.switchVersion("testpy", "3.11.7"); err != nil {
+		t.Fatalf("switchVersion() エラー: %v", err)
+	}
+
+	tests := []struct {
+		constraint string
+		want       string
+		wantReason string
+		wantErr    bool
+	}{
+		{constraint: ">=3.11", want: "3.11.7", wantReason: "有効なバージョン"},
+		{constraint: "~=3.12", want: "3.12.1", wantReason: "インストール済み"},
+		{constraint: "<3.11", want: "3.10.4", wantReason: "インストール済み"},
+		{constraint: "3.10 || 3.11 || 3.12", want: "3.11.7", wantReason: "有効なバージョン"},
+		{constraint: ">3.11", want: "3.12.1", wantReason: "インストール済み"},
+		{constraint: ">=3.11, !=3.11.7", want: "3.12.1", wantReason: "インストール済み"},
+		{constraint: ">=3.13", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			req, err := parseVersionConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("parseVersionConstraint() エラー: %v", err)
+			}
+
+			got, reason, err := m.ProposeVersion(DetectedTool{Tool: "testpy", Constraint: tt.constraint, req: req})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProposeVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want || reason != tt.wantReason {
+				t.Errorf("ProposeVersion() = %q (%s), want %q (%s)", got, reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package version
+
+import (
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// ツールが設定する環境変数 1 件を表す
+type EnvVar struct {
+	Tool  string
+	Name  string
+	Value string
+}
+
+// アクティブな全ツールのプラグイン env_vars を解決して返す
+// ツール名、変数名の順でソートされる
+func (m *Manager) EnvVars() ([]EnvVar, error) {
+	currentAll, err := m.CurrentAll()
+	if err != nil {
+		return nil, err
+	}
+
+	tools := make([]string, 0, len(currentAll))
+	for tool := range currentAll {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+
+	var result []EnvVar
+	for _, tool := range tools {
+		result = append(result, m.ToolEnvVars(tool, currentAll[tool])...)
+	}
+
+	return result, nil
+}
+
+// 指定バージョンのツールが設定する環境変数を返す
+// 未知のツールや env_vars を持たないツールは空を返す
+func (m *Manager) ToolEnvVars(toolName, version string) []EnvVar {
+	return m.toolEnvVars(toolName, version, m.paths.ToolCurrentPath(toolName))
+}
+
+// current_dir に渡すパスを指定して環境変数を解決する
+func (m *Manager) toolEnvVars(toolName, version, currentDir string) []EnvVar {
+	p, err := m.registry.Get(toolName)
+	if err != nil || len(p.EnvVars) == 0 {
+		return nil
+	}
+
+	vars := p.ResolveEnvVars(
+		m.paths.ToolVersionPath(toolName, version),
+		version,
+		currentDir,
+	)
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := make([]EnvVar, 0, len(names))
+	for _, name := range names {
+		result = append(result, EnvVar{Tool: toolName, Name: name, Value: vars[name]})
+	}
+	return result
+}
+
+// 指定したバージョンを使うプロセスの環境変数を environ ("KEY=VALUE" 形式) から作る
+// インストール済みの各バージョンの bin ディレクトリを PATH の先頭に追加し、
+// プラグインの env_vars を設定する
+func (m *Manager) ProcessEnv(environ []string, resolutions []Resolution) []string {
+	binDirs := m.ResolvedBinDirs(resolutions)
+
+	overrides := make(map[string]string)
+	for _, v := range m.ResolvedEnvVars(resolutions) {
+		overrides[v.Name] = v.Value
+	}
+
+	result := make([]string, 0, len(environ)+len(overrides)+1)
+	pathSet := false
+	for _, kv := range environ {
+		key, value, _ := strings.Cut(kv, "=")
+
+		if isPathKey(key) {
+			pathSet = true
+			if len(binDirs) > 0 {
+				kv = key + "=" + strings.Join(binDirs, string(os.PathListSeparator))
+				if value != "" {
+					kv += string(os.PathListSeparator) + value
+				}
+			}
+		} else if v, ok := overrides[key]; ok {
+			kv = key + "=" + v
+			delete(overrides, key)
+		}
+		result = append(result, kv)
+	}
+
+	if !pathSet && len(binDirs) > 0 {
+		result = append(result, "PATH="+strings.Join(binDirs, string(os.PathListSeparator)))
+	}
+
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, name+"="+overrides[name])
+	}
+
+	return result
+}
+
+// PATH 環境変数のキーか判定する（Windows では大文字小文字を区別しない）
+func isPathKey(key string) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(key, "PATH")
+	}
+	return key == "PATH"
+}
@@ -0,0 +1,134 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/plugin"
+)
+
+// テスト用の Manager を作成するヘルパー関数
+func newTestManager(t *testing.T, pluginFiles map[string]string) (*Manager, *config.Paths) {
+	t.Helper()
+
+	tmpDir := t.TempDir()
+	paths := &config.Paths{
+		Root:     filepath.Join(tmpDir, "arsenal"),
+		Versions: filepath.Join(tmpDir, "arsenal", "versions"),
+		Current:  filepath.Join(tmpDir, "arsenal", "current"),
+		Plugins:  filepath.Join(tmpDir, "arsenal", "plugins"),
+		Bin:      filepath.Join(tmpDir, "arsenal", "bin"),
+		Links:    filepath.Join(tmpDir, "arsenal", "links"),
+	}
+
+	if err := paths.EnsureDirs(); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	for name, content := range pluginFiles {
+		if err := os.WriteFile(filepath.Join(paths.Plugins, name), []byte(content), 0644); err != nil {
+			t.Fatalf("プラグインファイル作成エラー: %v", err)
+		}
+	}
+
+	registry, err := plugin.NewRegistry(paths)
+	if err != nil {
+		t.Fatalf("レジストリ作成エラー: %v", err)
+	}
+
+	return NewManager(paths, registry), paths
+}
+
+// アクティブなツールの env_vars が解決されるかテストする
+func TestManagerEnvVars(t *testing.T) {
+	m, paths := newTestManager(t, map[string]string{
+		"testgo.toml": `name = "testgo"
+display_name = "Test Go"
+
+[env_vars]
+GOROOT = "{{install_dir}}"
+GOTOOLCHAIN = "go{{version}}"
+`,
+	})
+
+	versionDir := paths.ToolVersionPath("testgo", "1.22.0")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+	}
+	if err := os.Symlink(versionDir, paths.ToolCurrentPath("testgo")); err != nil {
+		t.Fatalf("symlink 作成エラー: %v", err)
+	}
+
+	vars, err := m.EnvVars()
+	if err != nil {
+		t.Fatalf("EnvVars() エラー: %v", err)
+	}
+
+	expected := []EnvVar{
+		{Tool: "testgo", Name: "GOROOT", Value: versionDir},
+		{Tool: "testgo", Name: "GOTOOLCHAIN", Value: "go1.22.0"},
+	}
+
+	if len(vars) != len(expected) {
+		t.Fatalf("環境変数の数 = %d, want %d", len(vars), len(expected))
+	}
+	for i, want := range expected {
+		if vars[i] != want {
+			t.Errorf("vars[%d] = %+v, want %+v", i, vars[i], want)
+		}
+	}
+}
+
+// env_vars を持たないツールでは空を返すかテストする
+func TestManagerToolEnvVarsEmpty(t *testing.T) {
+	m, _ := newTestManager(t, nil)
+
+	if vars := m.ToolEnvVars("node", "20.10.0"); len(vars) != 0 {
+		t.Errorf("ToolEnvVars(node) = %v, want empty", vars)
+	}
+	if vars := m.ToolEnvVars("nonexistent", "1.0.0"); len(vars) != 0 {
+		t.Errorf("ToolEnvVars(nonexistent) = %v, want empty", vars)
+	}
+}
+
+// 解決したバージョンの PATH と env_vars がプロセスの環境に反映されるかテストする
+func TestManagerProcessEnv(t *testing.T) {
+	m, paths := newTestManager(t, map[string]string{
+		"testgo.toml": `name = "testgo"
+
+[env_vars]
+GOROOT = "{{install_dir}}"
+`,
+	})
+
+	versionDir := paths.ToolVersionPath("testgo", "1.22.0")
+	resolutions := []Resolution{
+		{Tool: "testgo", Version: "1.22.0", Source: SourceToolVersions, Installed: true},
+		{Tool: "node", Version: "18.19.0", Source: SourceToolVersions, Installed: false},
+	}
+
+	sep := string(os.PathListSeparator)
+	env := m.ProcessEnv([]string{"HOME=/home/u", "PATH=/usr/bin", "GOROOT=/old"}, resolutions)
+
+	expected := []string{
+		"HOME=/home/u",
+		"PATH=" + filepath.Join(versionDir, "bin") + sep + "/usr/bin",
+		"GOROOT=" + versionDir,
+	}
+	if len(env) != len(expected) {
+		t.Fatalf("ProcessEnv() = %v, want %v", env, expected)
+	}
+	for i, want := range expected {
+		if env[i] != want {
+			t.Errorf("env[%d] = %q, want %q", i, env[i], want)
+		}
+	}
+
+	// 元の環境にない変数は追加される
+	env = m.ProcessEnv(nil, resolutions)
+	if len(env) != 2 || env[1] != "GOROOT="+versionDir {
+		t.Errorf("ProcessEnv(nil) = %v", env)
+	}
+}
@@ -0,0 +1,176 @@
+package version
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// .gitignore の 1 行のパターン
+type ignoreRule struct {
+	base     string // .gitignore があるディレクトリ
+	pattern  string
+	negate   bool // ! で始まる（除外の取り消し）
+	dirOnly  bool // / で終わる（ディレクトリのみ）
+	anchored bool // / を含む（base からの相対パスで照合）
+}
+
+// ディレクトリの .gitignore を読み込む（なければ空）
+func loadGitignore(dir string) []ignoreRule {
+	return loadIgnoreFile(filepath.Join(dir, ".gitignore"), dir)
+}
+
+// .gitignore と同じ書式のファイルを base からの相対パスのパターンとして読み込む（なければ空）
+func loadIgnoreFile(path, base string) []ignoreRule {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var rules []ignoreRule
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule := ignoreRule{base: base}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// パスがパターンに一致するか判定する
+func (r ignoreRule) match(p string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+
+	rel, err := filepath.Rel(r.base, p)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	if !r.anchored {
+		ok, _ := path.Match(r.pattern, path.Base(rel))
+		return ok
+	}
+	return matchSegments(strings.Split(r.pattern, "/"), strings.Split(rel, "/"))
+}
+
+// / 区切りのパターンをパスの要素ごとに照合する（** は 0 個以上の要素に一致する）
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], segments[0])
+	return ok && matchSegments(pattern[1:], segments[1:])
+}
+
+// 後に書かれたパターンほど優先して、パスが無視されるか判定する
+func isIgnored(rules []ignoreRule, p string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.match(p, isDir) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
+
+// root を含む git リポジトリで、root より上にあるディレクトリの無視パターンを返す
+// .git/info/exclude、リポジトリのルートから root の親までの .gitignore の順に並ぶ（後ほど優先）
+// git リポジトリの外なら空を返す
+func ancestorIgnoreRules(root string) []ignoreRule {
+	var ancestors []string // root の親からリポジトリのルートまで
+	repo := root
+	for {
+		if _, err := os.Stat(filepath.Join(repo, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(repo)
+		if parent == repo {
+			return nil
+		}
+		repo = parent
+		ancestors = append(ancestors, repo)
+	}
+
+	rules := loadIgnoreFile(filepath.Join(repo, ".git", "info", "exclude"), repo)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		rules = append(rules, loadGitignore(ancestors[i])...)
+	}
+	return rules
+}
+
+// root 以下で names のいずれかのファイルがあるディレクトリをパス順で返す
+// .git と、.gitignore（root を含むリポジトリの上位ディレクトリのものと .git/info/exclude を含む）で
+// 無視されるパスは探さない（シンボリックリンクは辿らない）
+func findFilesRespectingGitignore(root string, names []string) ([]string, error) {
+	var dirs []string
+
+	var walk func(dir string, rules []ignoreRule) error
+	walk = func(dir string, rules []ignoreRule) error {
+		rules = append(rules[:len(rules):len(rules)], loadGitignore(dir)...)
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, e := range entries {
+			p := filepath.Join(dir, e.Name())
+			if e.Name() == ".git" || isIgnored(rules, p, e.IsDir()) {
+				continue
+			}
+			if e.IsDir() {
+				if err := walk(p, rules); err != nil {
+					return err
+				}
+				continue
+			}
+			if !found && slices.Contains(names, e.Name()) {
+				found = true
+				dirs = append(dirs, dir)
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root, ancestorIgnoreRules(root)); err != nil {
+		return nil, err
+	}
+	sort.Strings(dirs)
+	return dirs, nil
+}
@@ -0,0 +1,130 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// .gitignore のパターンの照合をテストする
+func TestIgnoreRuleMatch(t *testing.T) {
+	base := filepath.FromSlash("/repo")
+	tests := []struct {
+		line  string
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"node_modules/", "node_modules", true, true},
+		{"node_modules/", "web/node_modules", true, true},
+		{"node_modules/", "node_modules", false, false},
+		{"/build", "build", true, true},
+		{"/build", "web/build", true, false},
+		{"tmp*", "web/tmp-cache", true, true},
+		{"docs/*.md", "docs/a.md", false, true},
+		{"docs/*.md", "web/docs/a.md", false, false},
+		{"**/fixtures", "a/b/fixtures", true, true},
+		{"vendor/**", "vendor/x", true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line+" "+tt.path, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(tt.line+"\n"), 0644); err != nil {
+				t.Fatalf("テストファイル作成エラー: %v", err)
+			}
+			rules := loadGitignore(dir)
+			if len(rules) != 1 {
+				t.Fatalf("loadGitignore() = %+v", rules)
+			}
+			rules[0].base = base
+
+			got := rules[0].match(filepath.Join(base, filepath.FromSlash(tt.path)), tt.isDir)
+			if got != tt.want {
+				t.Errorf("match(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// .gitignore で無視されるディレクトリを探さないかテストする
+func TestFindFilesRespectingGitignore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".gitignore":                                "node_modules/\n/build\ntmp*\n!tmp-keep\n",
+		".toolversions":                             "",
+		"services/api/.toolversions":                "",
+		"services/web/.toolversions":                "",
+		"services/.gitignore":                       "legacy\n",
+		"services/legacy/.toolversions":             "",
+		"services/web/node_modules/x/.toolversions": "",
+		"build/.toolversions":                       "",
+		"tmp-cache/.toolversions":                   "",
+		"tmp-keep/.toolversions":                    "",
+		".git/.toolversions":                        "",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成エラー: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("テストファイル作成エラー: %v", err)
+		}
+	}
+
+	dirs, err := findFilesRespectingGitignore(root, []string{".toolversions"})
+	if err != nil {
+		t.Fatalf("findFilesRespectingGitignore() エラー: %v", err)
+	}
+
+	want := []string{
+		root,
+		filepath.Join(root, "services", "api"),
+		filepath.Join(root, "services", "web"),
+		filepath.Join(root, "tmp-keep"),
+	}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Errorf("findFilesRespectingGitignore() = %v, want %v", dirs, want)
+	}
+}
+
+// root より上のリポジトリ内の .gitignore と .git/info/exclude にも従うかテストする
+func TestFindFilesRespectingAncestorGitignore(t *testing.T) {
+	repo := t.TempDir()
+	files := map[string]string{
+		".git/info/exclude":                         "scratch/\n",
+		".gitignore":                                "node_modules/\n",
+		"services/api/.tool-versions":               "",
+		"services/web/.toolversions":                "",
+		"services/web/node_modules/x/.toolversions": "",
+		"services/scratch/.toolversions":            "",
+		"services/legacy/.nvmrc":                    "",
+		"services/other/README.md":                  "",
+	}
+	for name, content := range files {
+		path := filepath.Join(repo, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成エラー: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("テストファイル作成エラー: %v", err)
+		}
+	}
+
+	root := filepath.Join(repo, "services")
+	dirs, err := findFilesRespectingGitignore(root, []string{".toolversions", ".tool-versions", ".nvmrc"})
+	if err != nil {
+		t.Fatalf("findFilesRespectingGitignore() エラー: %v", err)
+	}
+
+	want := []string{
+		filepath.Join(root, "api"),
+		filepath.Join(root, "legacy"),
+		filepath.Join(root, "web"),
+	}
+	if !reflect.DeepEqual(dirs, want) {
+		t.Errorf("findFilesRespectingGitignore() = %v, want %v", dirs, want)
+	}
+}
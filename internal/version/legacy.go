@@ -0,0 +1,69 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// プラグインの legacy_files で宣言されたバージョンファイルからバージョンを優先順で読み込む
+//
+//	go.mod          toolchain 行（例: toolchain go1.22.1 -> 1.22.1）
+//	それ以外        1 行 1 バージョンのテキスト（.nvmrc, .node-version, .python-version など）
+//
+// バージョンとして扱えない値（lts/*, system などのエイリアス）は無視する
+func readLegacyVersionFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if filepath.Base(path) == "go.mod" {
+		return parseGoModToolchain(string(data)), nil
+	}
+	return parsePlainVersionFile(string(data)), nil
+}
+
+// 1 行 1 バージョンのファイルを読む（# 以降はコメント、先頭の v は除く）
+func parsePlainVersionFile(content string) []string {
+	var versions []string
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		// 1 行に空白区切りで複数書く形式（pyenv など）も受け付ける
+		for _, f := range fields {
+			if v := normalizeLegacyVersion(f); v != "" {
+				versions = append(versions, v)
+			}
+		}
+	}
+	return versions
+}
+
+// go.mod の toolchain 行からバージョンを読む（toolchain 行がなければ空）
+func parseGoModToolchain(content string) []string {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "toolchain" {
+			if v := normalizeLegacyVersion(strings.TrimPrefix(fields[1], "go")); v != "" {
+				return []string{v}
+			}
+		}
+	}
+	return nil
+}
+
+// 先頭の v を除き、数字で始まらない値（エイリアス）は空を返す
+func normalizeLegacyVersion(v string) string {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if v == "" || v[0] < '0' || v[0] > '9' {
+		return ""
+	}
+	return v
+}
@@ -0,0 +1,166 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// go.mod を legacy_files に持ち、asdf では golang と呼ばれるテストプラグイン
+const legacyGoPlugin = `name = "testgo"
+download_url = "https://example.com/go{{version}}.tar.gz"
+legacy_files = ["go.mod"]
+asdf_names = ["golang"]
+`
+
+// 1 行 1 バージョンのファイルの解析をテストする
+func TestParsePlainVersionFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"バージョンのみ", "20.10.0\n", []string{"20.10.0"}},
+		{"先頭の v", "v20.10.0\n", []string{"20.10.0"}},
+		{"CRLF", "18.19.0\r\n", []string{"18.19.0"}},
+		{"コメント", "# LTS\n20 # 最新\n", []string{"20"}},
+		{"複数バージョン", "3.12.1 3.11.7\n", []string{"3.12.1", "3.11.7"}},
+		{"エイリアスは無視", "lts/iron\n", nil},
+		{"空", "\n\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parsePlainVersionFile(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsePlainVersionFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// go.mod の toolchain 行の解析をテストする
+func TestParseGoModToolchain(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{"toolchain あり", "module example.com/foo\n\ngo 1.22\n\ntoolchain go1.22.1\n", []string{"1.22.1"}},
+		{"toolchain なし", "module example.com/foo\n\ngo 1.22\n", nil},
+		{"toolchain default", "module example.com/foo\n\ntoolchain default\n", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseGoModToolchain(tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseGoModToolchain() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// 同じディレクトリ内のバージョンファイルの優先順をテストする
+func TestReadToolVersionsLegacyFiles(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantTools  map[string][]string
+		wantOrigin map[string]string
+	}{
+		{
+			name:       ".nvmrc",
+			files:      map[string]string{".nvmrc": "v20.10.0\n"},
+			wantTools:  map[string][]string{"node": {"20.10.0"}},
+			wantOrigin: map[string]string{"node": ".nvmrc"},
+		},
+		{
+			name:       ".nvmrc を .node-version より優先",
+			files:      map[string]string{".nvmrc": "20.10.0\n", ".node-version": "18.19.0\n"},
+			wantTools:  map[string][]string{"node": {"20.10.0"}},
+			wantOrigin: map[string]string{"node": ".nvmrc"},
+		},
+		{
+			name:       ".tool-versions を legacy_files より優先",
+			files:      map[string]string{".tool-versions": "node 18.19.0\n", ".nvmrc": "20.10.0\n"},
+			wantTools:  map[string][]string{"node": {"18.19.0"}},
+			wantOrigin: map[string]string{"node": ".tool-versions"},
+		},
+		{
+			name:       ".toolversions を .tool-versions より優先",
+			files:      map[string]string{".toolversions": "node 20.10.0\n", ".tool-versions": "node 18.19.0\ntestgo 1.22.1\n"},
+			wantTools:  map[string][]string{"node": {"20.10.0"}, "testgo": {"1.22.1"}},
+			wantOrigin: map[string]string{"node": ".toolversions", "testgo": ".tool-versions"},
+		},
+		{
+			name:       ".tool-versions の asdf のプラグイン名",
+			files:      map[string]string{".tool-versions": "nodejs 18.19.0\ngolang 1.22.1\nruby 3.3.0\n"},
+			wantTools:  map[string][]string{"node": {"18.19.0"}, "testgo": {"1.22.1"}, "ruby": {"3.3.0"}},
+			wantOrigin: map[string]string{"node": ".tool-versions", "testgo": ".tool-versions", "ruby": ".tool-versions"},
+		},
+		{
+			name:       "go.mod の toolchain",
+			files:      map[string]string{"go.mod": "module example.com/foo\n\ngo 1.22\n\ntoolchain go1.22.1\n", ".nvmrc": "20\n"},
+			wantTools:  map[string][]string{"node": {"20"}, "testgo": {"1.22.1"}},
+			wantOrigin: map[string]string{"node": ".nvmrc", "testgo": "go.mod"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _ := newTestManager(t, map[string]string{"testgo.toml": legacyGoPlugin})
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+					t.Fatalf("テストファイル作成エラー: %v", err)
+				}
+			}
+
+			tv, err := m.ReadToolVersions(dir, nil)
+			if err != nil {
+				t.Fatalf("ReadToolVersions() エラー: %v", err)
+			}
+
+			if !reflect.DeepEqual(tv.Tools, tt.wantTools) {
+				t.Errorf("Tools = %v, want %v", tv.Tools, tt.wantTools)
+			}
+			for tool, name := range tt.wantOrigin {
+				if want := filepath.Join(dir, name); tv.Origins[tool] != want {
+					t.Errorf("Origins[%s] = %q, want %q", tool, tv.Origins[tool], want)
+				}
+			}
+		})
+	}
+}
+
+// バージョンを読めない legacy ファイルでは上位ディレクトリの検索を続けることをテストする
+func TestReadToolVersionsLegacyFileWithoutVersion(t *testing.T) {
+	m, _ := newTestManager(t, map[string]string{"testgo.toml": legacyGoPlugin})
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "module")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "go.mod"), []byte("module example.com/foo\n\ngo 1.22\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+	tvFile := filepath.Join(root, ".toolversions")
+	if err := os.WriteFile(tvFile, []byte("testgo 1.21.5\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+
+	tv, err := m.ReadToolVersions(sub, nil)
+	if err != nil {
+		t.Fatalf("ReadToolVersions() エラー: %v", err)
+	}
+
+	if !reflect.DeepEqual(tv.Tools["testgo"], []string{"1.21.5"}) {
+		t.Errorf("Tools[testgo] = %v, want [1.21.5]", tv.Tools["testgo"])
+	}
+	if tv.Origins["testgo"] != tvFile {
+		t.Errorf("Origins[testgo] = %q, want %q", tv.Origins["testgo"], tvFile)
+	}
+}
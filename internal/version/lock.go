@@ -0,0 +1,295 @@
+package version
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/plugin"
+	"github.com/arsenal/internal/terminal"
+)
+
+// ロックファイルのフォーマットのバージョン
+const lockFileVersion = 1
+
+const lockFileHeader = "# bastion-arsenal sync / lock が生成したファイルです。手動で編集しないでください\n\n"
+
+// .toolversions.lock の内容を表す
+// .toolversions の各指定について、解決された正確なバージョンとプラットフォームごとの
+// ダウンロード URL、アーカイブの SHA-256 を記録する
+type LockFile struct {
+	Version int          `toml:"version"`
+	Tools   []LockedTool `toml:"tool"`
+}
+
+// ツールの 1 つのバージョン指定に対応するエントリ
+type LockedTool struct {
+	Name      string           `toml:"name"`
+	Spec      string           `toml:"spec"`    // .toolversions に書かれた指定（例: 20）
+	Version   string           `toml:"version"` // 解決されたバージョン（例: 20.10.0）
+	Platforms []LockedPlatform `toml:"platform"`
+}
+
+// プラットフォームごとのダウンロード元
+type LockedPlatform struct {
+	Platform string `toml:"platform"` // 例: linux/amd64
+	URL      string `toml:"url"`
+	SHA256   string `toml:"sha256,omitempty"` // アーカイブを取得していなければ空
+}
+
+// ロックファイルを読み込む（存在しなければ空のロックファイルを返す）
+func LoadLockFile(path string) (*LockFile, error) {
+	lock := &LockFile{Version: lockFileVersion}
+	if _, err := toml.DecodeFile(path, lock); err != nil {
+		if os.IsNotExist(err) {
+			return lock, nil
+		}
+		return nil, fmt.Errorf("%s の読み込みエラー: %w", path, err)
+	}
+
+	if lock.Version > lockFileVersion {
+		return nil, fmt.Errorf("%s はこのバージョンの Arsenal より新しい形式です (version = %d)", path, lock.Version)
+	}
+	return lock, nil
+}
+
+// ロックファイルを書き込む（内容が変わらなければ書き込まない）
+func (l *LockFile) Save(path string) error {
+	var buf bytes.Buffer
+	buf.WriteString(lockFileHeader)
+	if err := toml.NewEncoder(&buf).Encode(l); err != nil {
+		return err
+	}
+
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, buf.Bytes()) {
+		return nil
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// ツールとバージョン指定に対応するエントリを返す（なければ nil）
+func (l *LockFile) Find(tool, spec string) *LockedTool {
+	for i := range l.Tools {
+		if l.Tools[i].Name == tool && l.Tools[i].Spec == spec {
+			return &l.Tools[i]
+		}
+	}
+	return nil
+}
+
+// プラットフォームのエントリを返す（なければ nil）
+func (t *LockedTool) Platform(platform plugin.Platform) *LockedPlatform {
+	for i := range t.Platforms {
+		if t.Platforms[i].Platform == platform.String() {
+			return &t.Platforms[i]
+		}
+	}
+	return nil
+}
+
+// プラットフォームのエントリを追加または置き換える（プラットフォーム名順を保つ）
+func (t *LockedTool) setPlatform(entry LockedPlatform) {
+	for i := range t.Platforms {
+		if t.Platforms[i].Platform == entry.Platform {
+			t.Platforms[i] = entry
+			return
+		}
+		if t.Platforms[i].Platform > entry.Platform {
+			t.Platforms = append(t.Platforms[:i], append([]LockedPlatform{entry}, t.Platforms[i:]...)...)
+			return
+		}
+	}
+	t.Platforms = append(t.Platforms, entry)
+}
+
+// 実行中のプラットフォーム
+func currentPlatform() plugin.Platform {
+	return plugin.Platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+}
+
+// ロックファイルのパスを返す
+// バージョンファイルが見つかった最も近いディレクトリに置く（選んだプロファイルによらない）
+func (tv *ToolVersions) LockPath() string {
+	return filepath.Join(tv.nearestDir, config.ToolVersionLockFile)
+}
+
+// dir でロックする全てのバージョン指定を返す
+// ベースのみと、各プロファイルを 1 つ選んだ場合に同期される指定の和になる
+// （複数のプロファイルを重ねた場合の指定はこのいずれかに含まれる）
+func (m *Manager) lockableSpecs(dir string) (*ToolVersions, map[string][]string, error) {
+	base, err := m.ReadToolVersions(dir, []string{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	specs := make(map[string][]string)
+	add := func(tv *ToolVersions) {
+		for tool, versions := range tv.Tools {
+			if m.isUnknownAsdfTool(tv, tool) {
+				continue
+			}
+			for _, v := range versions {
+				if !slices.Contains(specs[tool], v) {
+					specs[tool] = append(specs[tool], v)
+				}
+			}
+		}
+	}
+
+	add(base)
+	for _, profile := range base.Profiles {
+		tv, err := m.ReadToolVersions(dir, []string{profile})
+		if err != nil {
+			return nil, nil, err
+		}
+		add(tv)
+	}
+	return base, specs, nil
+}
+
+// dir で同期される全てのバージョン指定（全プロファイルを含む）を解決し、プラグインが対応する
+// 全プラットフォームのダウンロード URL と SHA-256 をロックファイルに書き込む
+// 既にロックされている指定はそのバージョンを保ち、記録済みの URL のハッシュは再計算しない
+func (m *Manager) Lock(dir string) (string, error) {
+	tv, specs, err := m.lockableSpecs(dir)
+	if err != nil {
+		return "", fmt.Errorf(".toolversions 読み込みエラー: %w", err)
+	}
+
+	path := tv.LockPath()
+	old, err := LoadLockFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	lock := &LockFile{Version: lockFileVersion}
+	for _, tool := range sortedTools(specs) {
+		p, err := m.registry.Get(tool)
+		if err != nil {
+			return "", err
+		}
+
+		for _, spec := range specs[tool] {
+			prev := old.Find(tool, spec)
+			entry := LockedTool{Name: tool, Spec: spec}
+			if prev != nil {
+				entry.Version = prev.Version
+			} else {
+				entry.Version = m.resolveSpec(tool, spec)
+			}
+
+			terminal.PrintfCyan("── %s %s ──\n", tool, entry.Version)
+			for _, platform := range p.Platforms() {
+				url := p.ResolveDownloadURLFor(entry.Version, platform)
+				if prev != nil && prev.Version == entry.Version {
+					if pp := prev.Platform(platform); pp != nil && pp.URL == url && pp.SHA256 != "" {
+						entry.setPlatform(*pp)
+						continue
+					}
+				}
+
+				fmt.Printf("   %s\n", platform)
+				sum, err := m.hashURL(url)
+				if err != nil {
+					// 古いバージョンなど一部のプラットフォームにしか配布されていないものは飛ばす
+					terminal.PrintWarning("%s %s (%s) を取得できません: %v", tool, entry.Version, platform, err)
+					continue
+				}
+				entry.setPlatform(LockedPlatform{Platform: platform.String(), URL: url, SHA256: sum})
+			}
+			lock.Tools = append(lock.Tools, entry)
+		}
+	}
+
+	if err := lock.Save(path); err != nil {
+		return "", fmt.Errorf("%s 書き込みエラー: %w", path, err)
+	}
+	return path, nil
+}
+
+// アーカイブをダウンロードして SHA-256 を返す
+func (m *Manager) hashURL(url string) (string, error) {
+	tmpFile, sum, err := m.download(url)
+	if err != nil {
+		return "", err
+	}
+	_ = os.Remove(tmpFile)
+	return sum, nil
+}
+
+// .toolversions の指定を正確なバージョンに解決する
+// インストール済みのバージョンと完全に一致すればそれを使い、それ以外はリモートの一覧から
+// 一致する最新のバージョンを探す。一覧を取得できなければ一致するインストール済みの最新、
+// それもなければ指定をそのままバージョンとして扱う
+func (m *Manager) resolveSpec(tool, spec string) string {
+	if m.isInstalled(tool, spec) {
+		return spec
+	}
+	if ver, err := m.FindRemote(tool, spec); err == nil {
+		return ver
+	}
+	if ver, err := m.FindInstalled(tool, spec); err == nil && ver != "" {
+		return ver
+	}
+	return spec
+}
+
+// --frozen の同期で、ロックファイルと .toolversions / プラグイン定義の食い違いを返す
+// tv は同期する指定、specs は全プロファイルを含むロック対象の指定
+func (m *Manager) lockDrift(tv *ToolVersions, specs map[string][]string, lock *LockFile) []string {
+	var drift []string
+	platform := currentPlatform()
+
+	for _, tool := range sortedTools(tv.Tools) {
+		if m.isUnknownAsdfTool(tv, tool) {
+			continue
+		}
+		p, err := m.registry.Get(tool)
+		if err != nil {
+			drift = append(drift, err.Error())
+			continue
+		}
+
+		for _, spec := range tv.Tools[tool] {
+			locked := lock.Find(tool, spec)
+			if locked == nil {
+				drift = append(drift, fmt.Sprintf("%s %s がロックされていません", tool, spec))
+				continue
+			}
+			if !MatchesVersionSpec(spec, locked.Version) {
+				drift = append(drift, fmt.Sprintf("%s %s のロックされたバージョン %s が指定に一致しません", tool, spec, locked.Version))
+				continue
+			}
+			if m.isInstalled(tool, locked.Version) {
+				continue
+			}
+
+			entry := locked.Platform(platform)
+			switch {
+			case entry == nil || entry.SHA256 == "":
+				drift = append(drift, fmt.Sprintf("%s %s の %s 用のチェックサムがありません (bastion-arsenal lock で記録)", tool, locked.Version, platform))
+			case entry.URL != p.ResolveDownloadURLFor(locked.Version, platform):
+				drift = append(drift, fmt.Sprintf("%s %s のダウンロード URL がプラグイン定義と異なります (%s)", tool, locked.Version, entry.URL))
+			}
+		}
+	}
+
+	for _, locked := range lock.Tools {
+		if !slices.Contains(specs[locked.Name], locked.Spec) {
+			drift = append(drift, fmt.Sprintf("%s %s は .toolversions にありません", locked.Name, locked.Spec))
+		}
+	}
+
+	return drift
+}
+
+// ロックファイルの食い違いをまとめたエラーを作る
+func driftError(path string, drift []string) error {
+	return fmt.Errorf("%s が .toolversions と一致しません (bastion-arsenal lock で更新):\n  %s", path, strings.Join(drift, "\n  "))
+}
@@ -0,0 +1,440 @@
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+)
+
+// 空の tar.gz（最小限のヘッダー）
+var emptyTarGz = []byte{
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff,
+	0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+// ダミーのアーカイブを返すサーバーと、それを使うテストプラグインを用意する
+// 戻り値のカウンタはアーカイブのダウンロード回数
+func setupLockTest(t *testing.T) (*Manager, *config.Paths, *int32) {
+	t.Helper()
+
+	var downloads int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&downloads, 1)
+		_, _ = w.Write(emptyTarGz)
+	}))
+	t.Cleanup(server.Close)
+
+	pluginContent := `name = "testnode"
+download_url = "` + server.URL + `/node-v{{version}}-{{os}}-{{arch}}.tar.gz"
+archive_type = "tar.gz"
+
+[os_map]
+linux = "linux"
+darwin = "darwin"
+
+[arch_map]
+amd64 = "x64"
+`
+	m, paths := newTestManager(t, map[string]string{"testnode.toml": pluginContent})
+	return m, paths, &downloads
+}
+
+func emptyTarGzSHA256() string {
+	sum := sha256.Sum256(emptyTarGz)
+	return hex.EncodeToString(sum[:])
+}
+
+// ロックファイルの書き込みと読み込みをテストする
+func TestLockFileSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), config.ToolVersionLockFile)
+
+	lock := &LockFile{
+		Version: lockFileVersion,
+		Tools: []LockedTool{{
+			Name:    "node",
+			Spec:    "20",
+			Version: "20.10.0",
+			Platforms: []LockedPlatform{
+				{Platform: "linux/amd64", URL: "https://example.com/node-linux.tar.gz", SHA256: "abc"},
+				{Platform: "linux/arm64", URL: "https://example.com/node-linux-arm64.tar.gz"},
+			},
+		}},
+	}
+	if err := lock.Save(path); err != nil {
+		t.Fatalf("Save() エラー: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ファイル読み込みエラー: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "# ") {
+		t.Errorf("先頭にコメントがありません:\n%s", data)
+	}
+	if strings.Contains(string(data), `sha256 = ""`) {
+		t.Errorf("空の sha256 が書き込まれています:\n%s", data)
+	}
+
+	got, err := LoadLockFile(path)
+	if err != nil {
+		t.Fatalf("LoadLockFile() エラー: %v", err)
+	}
+	if !reflect.DeepEqual(got, lock) {
+		t.Errorf("LoadLockFile() = %+v, want %+v", got, lock)
+	}
+
+	if e := got.Find("node", "20"); e == nil || e.Version != "20.10.0" {
+		t.Errorf("Find(node, 20) = %+v", e)
+	}
+	if e := got.Find("node", "20.10.0"); e != nil {
+		t.Errorf("Find(node, 20.10.0) = %+v, want nil", e)
+	}
+}
+
+// ロックファイルがない場合と新しい形式の場合をテストする
+func TestLoadLockFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := LoadLockFile(filepath.Join(dir, "missing.lock"))
+	if err != nil || lock == nil || len(lock.Tools) != 0 {
+		t.Errorf("LoadLockFile(存在しない) = %+v, %v", lock, err)
+	}
+
+	newer := filepath.Join(dir, "newer.lock")
+	if err := os.WriteFile(newer, []byte("version = 99\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+	if _, err := LoadLockFile(newer); err == nil {
+		t.Error("新しい形式のロックファイルでエラーが返されませんでした")
+	}
+}
+
+// プラットフォームのエントリが名前順に保たれるかテストする
+func TestLockedToolSetPlatform(t *testing.T) {
+	var tool LockedTool
+	tool.setPlatform(LockedPlatform{Platform: "linux/amd64", URL: "a"})
+	tool.setPlatform(LockedPlatform{Platform: "darwin/arm64", URL: "b"})
+	tool.setPlatform(LockedPlatform{Platform: "windows/amd64", URL: "c"})
+	tool.setPlatform(LockedPlatform{Platform: "linux/amd64", URL: "d"})
+
+	want := []LockedPlatform{
+		{Platform: "darwin/arm64", URL: "b"},
+		{Platform: "linux/amd64", URL: "d"},
+		{Platform: "windows/amd64", URL: "c"},
+	}
+	if !reflect.DeepEqual(tool.Platforms, want) {
+		t.Errorf("Platforms = %+v, want %+v", tool.Platforms, want)
+	}
+}
+
+// ロックファイルがバージョンファイルの見つかった最も近いディレクトリに置かれるかテストする
+func TestToolVersionsLockPath(t *testing.T) {
+	m, _ := newTestManager(t, nil)
+
+	root := t.TempDir()
+	frontend := filepath.Join(root, "frontend")
+	if err := os.MkdirAll(frontend, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, config.ToolVersionFile), []byte("node 18.19.0\ngo 1.22.0\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+	// プロファイルにしか指定がなくても、ロックファイルの場所は変わらない
+	if err := os.WriteFile(filepath.Join(frontend, config.ToolVersionFile), []byte("[ci]\nnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+
+	want := filepath.Join(frontend, config.ToolVersionLockFile)
+	for _, profiles := range [][]string{{}, {"ci"}} {
+		tv, err := m.ReadToolVersions(frontend, profiles)
+		if err != nil {
+			t.Fatalf("ReadToolVersions() エラー: %v", err)
+		}
+		if got := tv.LockPath(); got != want {
+			t.Errorf("LockPath(%v) = %q, want %q", profiles, got, want)
+		}
+	}
+}
+
+// Lock が全プラットフォームのチェックサムを記録し、記録済みのものは再取得しないかテストする
+func TestManagerLock(t *testing.T) {
+	m, _, downloads := setupLockTest(t)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("testnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	path, err := m.Lock(projectDir)
+	if err != nil {
+		t.Fatalf("Lock() エラー: %v", err)
+	}
+	if want := filepath.Join(projectDir, config.ToolVersionLockFile); path != want {
+		t.Errorf("Lock() = %q, want %q", path, want)
+	}
+
+	lock, err := LoadLockFile(path)
+	if err != nil {
+		t.Fatalf("LoadLockFile() エラー: %v", err)
+	}
+	entry := lock.Find("testnode", "20.10.0")
+	if entry == nil {
+		t.Fatalf("testnode 20.10.0 がロックされていません: %+v", lock)
+	}
+
+	var platforms []string
+	for _, p := range entry.Platforms {
+		platforms = append(platforms, p.Platform)
+		if p.SHA256 != emptyTarGzSHA256() {
+			t.Errorf("%s の sha256 = %q", p.Platform, p.SHA256)
+		}
+	}
+	if want := []string{"darwin/amd64", "linux/amd64"}; !reflect.DeepEqual(platforms, want) {
+		t.Errorf("プラットフォーム = %v, want %v", platforms, want)
+	}
+	if !strings.HasSuffix(entry.Platforms[1].URL, "/node-v20.10.0-linux-x64.tar.gz") {
+		t.Errorf("URL = %q", entry.Platforms[1].URL)
+	}
+
+	// 2 回目は記録済みのチェックサムを使う
+	before := atomic.LoadInt32(downloads)
+	if _, err := m.Lock(projectDir); err != nil {
+		t.Fatalf("Lock() エラー: %v", err)
+	}
+	if after := atomic.LoadInt32(downloads); after != before {
+		t.Errorf("記録済みのアーカイブを %d 回ダウンロードしました", after-before)
+	}
+}
+
+// Sync がロックファイルを書き、--frozen で食い違いを検出するかテストする
+func TestManagerSyncLock(t *testing.T) {
+	m, paths, _ := setupLockTest(t)
+
+	projectDir := t.TempDir()
+	tvPath := filepath.Join(projectDir, config.ToolVersionFile)
+	if err := os.WriteFile(tvPath, []byte("testnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+	lockPath := filepath.Join(projectDir, config.ToolVersionLockFile)
+
+	// ロックファイルがなければ --frozen は失敗する
+	if err := m.Sync(projectDir, SyncOptions{Frozen: true}); err == nil {
+		t.Fatal("ロックファイルがないのに --frozen が成功しました")
+	}
+
+	if err := m.Sync(projectDir, SyncOptions{}); err != nil {
+		t.Fatalf("Sync() エラー: %v", err)
+	}
+
+	lock, err := LoadLockFile(lockPath)
+	if err != nil {
+		t.Fatalf("LoadLockFile() エラー: %v", err)
+	}
+	entry := lock.Find("testnode", "20.10.0")
+	if entry == nil {
+		t.Fatalf("testnode 20.10.0 がロックされていません: %+v", lock)
+	}
+	pe := entry.Platform(currentPlatform())
+	if pe == nil || pe.SHA256 != emptyTarGzSHA256() {
+		t.Errorf("現在のプラットフォームのエントリ = %+v", pe)
+	}
+
+	// ロックファイルと一致していれば --frozen でインストールできる
+	if err := os.RemoveAll(paths.ToolVersionPath("testnode", "20.10.0")); err != nil {
+		t.Fatalf("削除エラー: %v", err)
+	}
+	if err := m.Sync(projectDir, SyncOptions{Frozen: true}); err != nil {
+		t.Fatalf("Sync(frozen) エラー: %v", err)
+	}
+	if !m.isInstalled("testnode", "20.10.0") {
+		t.Error("--frozen でインストールされませんでした")
+	}
+
+	// .toolversions が変わると --frozen は失敗する
+	if err := os.WriteFile(tvPath, []byte("testnode 22.0.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 更新エラー: %v", err)
+	}
+	err = m.Sync(projectDir, SyncOptions{Frozen: true})
+	if err == nil || !strings.Contains(err.Error(), "testnode 22.0.0 がロックされていません") {
+		t.Errorf("Sync(frozen) = %v, want ロックされていないエラー", err)
+	}
+	if m.isInstalled("testnode", "22.0.0") {
+		t.Error("食い違いがあるのにインストールされました")
+	}
+}
+
+// インストール済みのバージョンでもチェックサムを記録し、別の環境の --frozen で使えるかテストする
+func TestManagerSyncLockInstalledVersion(t *testing.T) {
+	m, paths, downloads := setupLockTest(t)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("testnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+	versionDir := paths.ToolVersionPath("testnode", "20.10.0")
+	if err := os.MkdirAll(versionDir, 0755); err != nil {
+		t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+	}
+
+	if err := m.Sync(projectDir, SyncOptions{}); err != nil {
+		t.Fatalf("Sync() エラー: %v", err)
+	}
+	lock, err := LoadLockFile(filepath.Join(projectDir, config.ToolVersionLockFile))
+	if err != nil {
+		t.Fatalf("LoadLockFile() エラー: %v", err)
+	}
+	entry := lock.Find("testnode", "20.10.0")
+	if entry == nil {
+		t.Fatalf("testnode 20.10.0 がロックされていません: %+v", lock)
+	}
+	if pe := entry.Platform(currentPlatform()); pe == nil || pe.SHA256 != emptyTarGzSHA256() {
+		t.Errorf("現在のプラットフォームのエントリ = %+v", pe)
+	}
+
+	// 記録済みのチェックサムは再計算しない
+	before := atomic.LoadInt32(downloads)
+	if err := m.Sync(projectDir, SyncOptions{}); err != nil {
+		t.Fatalf("Sync() エラー: %v", err)
+	}
+	if got := atomic.LoadInt32(downloads); got != before {
+		t.Errorf("ダウンロード回数 = %d, want %d", got, before)
+	}
+
+	// 未インストールの環境でも --frozen で検証してインストールできる
+	if err := os.RemoveAll(versionDir); err != nil {
+		t.Fatalf("削除エラー: %v", err)
+	}
+	if err := m.Sync(projectDir, SyncOptions{Frozen: true}); err != nil {
+		t.Fatalf("Sync(frozen) エラー: %v", err)
+	}
+}
+
+// ロックファイルのチェックサムと一致しないアーカイブはインストールしないかテストする
+func TestManagerSyncChecksumMismatch(t *testing.T) {
+	m, paths, _ := setupLockTest(t)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("testnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	p, err := m.registry.Get("testnode")
+	if err != nil {
+		t.Fatalf("プラグイン取得エラー: %v", err)
+	}
+	platform := currentPlatform()
+	lock := &LockFile{Version: lockFileVersion, Tools: []LockedTool{{
+		Name:    "testnode",
+		Spec:    "20.10.0",
+		Version: "20.10.0",
+		Platforms: []LockedPlatform{{
+			Platform: platform.String(),
+			URL:      p.ResolveDownloadURLFor("20.10.0", platform),
+			SHA256:   strings.Repeat("0", 64),
+		}},
+	}}}
+	if err := lock.Save(filepath.Join(projectDir, config.ToolVersionLockFile)); err != nil {
+		t.Fatalf("Save() エラー: %v", err)
+	}
+
+	err = m.Sync(projectDir, SyncOptions{Frozen: true})
+	if err == nil || !strings.Contains(err.Error(), "チェックサムが一致しません") {
+		t.Errorf("Sync(frozen) = %v, want チェックサムの不一致エラー", err)
+	}
+	if _, err := os.Stat(paths.ToolVersionPath("testnode", "20.10.0")); !os.IsNotExist(err) {
+		t.Error("チェックサムが一致しないのにインストールされました")
+	}
+}
+
+// ロックされたバージョンがバージョン指定の解決に使われるかテストする
+func TestManagerResolveLockedSpec(t *testing.T) {
+	m, paths := newTestManager(t, nil)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("node 20\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	// ロックなし・未インストールなら指定のまま
+	r, err := m.ResolveTool(projectDir, "node")
+	if err != nil {
+		t.Fatalf("ResolveTool() エラー: %v", err)
+	}
+	if r.Version != "20" || r.Installed {
+		t.Errorf("ResolveTool() = %+v, want 20 (未インストール)", r)
+	}
+
+	lock := &LockFile{Version: lockFileVersion, Tools: []LockedTool{{Name: "node", Spec: "20", Version: "20.10.0"}}}
+	if err := lock.Save(filepath.Join(projectDir, config.ToolVersionLockFile)); err != nil {
+		t.Fatalf("Save() エラー: %v", err)
+	}
+
+	// ロックされたバージョンが未インストールならそれを表示
+	r, _ = m.ResolveTool(projectDir, "node")
+	if r.Version != "20.10.0" || r.Installed {
+		t.Errorf("ResolveTool() = %+v, want 20.10.0 (未インストール)", r)
+	}
+
+	// より新しい 20.x がインストールされていてもロックされたバージョンを使う
+	for _, v := range []string{"20.10.0", "20.11.0"} {
+		if err := os.MkdirAll(paths.ToolVersionPath("node", v), 0755); err != nil {
+			t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+		}
+	}
+	r, _ = m.ResolveTool(projectDir, "node")
+	if r.Version != "20.10.0" || !r.Installed {
+		t.Errorf("ResolveTool() = %+v, want 20.10.0", r)
+	}
+
+	// ロックがなければ一致する最新のインストール済みバージョン
+	if err := os.Remove(filepath.Join(projectDir, config.ToolVersionLockFile)); err != nil {
+		t.Fatalf("削除エラー: %v", err)
+	}
+	r, _ = m.ResolveTool(projectDir, "node")
+	if r.Version != "20.11.0" || !r.Installed {
+		t.Errorf("ResolveTool() = %+v, want 20.11.0", r)
+	}
+}
+
+// プロファイルを選んだ同期で、他のプロファイルのロックが消えないかテストする
+func TestManagerSyncProfileKeepsLock(t *testing.T) {
+	m, _, _ := setupLockTest(t)
+
+	projectDir := t.TempDir()
+	content := "testnode 20.10.0\n\n[node18-compat]\ntestnode 18.19.0\n"
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte(content), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+	lockPath := filepath.Join(projectDir, config.ToolVersionLockFile)
+
+	if err := m.Sync(projectDir, SyncOptions{Profiles: []string{"node18-compat"}}); err != nil {
+		t.Fatalf("Sync(node18-compat) エラー: %v", err)
+	}
+	if err := m.Sync(projectDir, SyncOptions{Profiles: []string{}}); err != nil {
+		t.Fatalf("Sync() エラー: %v", err)
+	}
+
+	lock, err := LoadLockFile(lockPath)
+	if err != nil {
+		t.Fatalf("LoadLockFile() エラー: %v", err)
+	}
+	for _, spec := range []string{"20.10.0", "18.19.0"} {
+		if lock.Find("testnode", spec) == nil {
+			t.Errorf("testnode %s がロックファイルに残っていません", spec)
+		}
+	}
+
+	// 定義されていないプロファイルはエラー
+	if err := m.Sync(projectDir, SyncOptions{Profiles: []string{"ci"}}); err == nil {
+		t.Error("未定義のプロファイルでエラーが返されませんでした")
+	}
+}
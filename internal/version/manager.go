@@ -4,6 +4,8 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -36,59 +38,119 @@ func NewManager(paths *config.Paths, registry *plugin.Registry) *Manager {
 
 // ツールの特定バージョンをダウンロードしてインストールする
 func (m *Manager) Install(toolName, version string) error {
+	_, err := m.install(toolName, version, installOptions{})
+	return err
+}
+
+// Install と同じだが、進捗を標準出力ではなく out に書き出す
+func (m *Manager) InstallWithOutput(toolName, version string, out io.Writer) error {
+	_, err := m.install(toolName, version, installOptions{out: out})
+	return err
+}
+
+// install の動作を指定する
+type installOptions struct {
+	// 空でなければ、アーカイブのハッシュが一致しない場合にインストールを中止する
+	sha256 string
+	// 進捗を表示せず、シムも更新しない（並列でインストールする呼び出し側がまとめて更新する）
+	quiet bool
+	// 進捗の出力先（nil なら標準出力）
+	out io.Writer
+}
+
+// インストールしてダウンロードしたアーカイブの SHA-256 を返す
+func (m *Manager) install(toolName, version string, opts installOptions) (string, error) {
 	p, err := m.registry.Get(toolName)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	out := opts.out
+	if out == nil {
+		out = os.Stdout
+	}
+	if opts.quiet {
+		out = io.Discard
 	}
 
 	installDir := m.paths.ToolVersionPath(toolName, version)
 
 	// 既にインストール済みか確認
 	if _, err := os.Stat(installDir); err == nil {
-		return fmt.Errorf("%s %s は既にインストール済みです", toolName, version)
+		return "", fmt.Errorf("%s %s は既にインストール済みです", toolName, version)
 	}
 
 	// インストールディレクトリを作成
 	if err := os.MkdirAll(installDir, 0755); err != nil {
-		return fmt.Errorf("インストールディレクトリ作成エラー: %w", err)
+		return "", fmt.Errorf("インストールディレクトリ作成エラー: %w", err)
 	}
 
 	// ダウンロード URL を解決
 	url := p.ResolveDownloadURL(version)
-	terminal.PrintInfo("%s %s をダウンロード中...", p.DisplayName, version)
-	fmt.Printf("   %s\n", url)
+	terminal.FprintInfo(out, "%s %s をダウンロード中...", p.DisplayName, version)
+	fmt.Fprintf(out, "   %s\n", url)
 
 	// ダウンロード
-	tmpFile, err := m.download(url)
+	progress := out
+	if opts.quiet {
+		progress = nil
+	}
+	tmpFile, sum, err := m.fetch(url, progress)
 	if err != nil {
 		_ = os.RemoveAll(installDir)
-		return fmt.Errorf("ダウンロードエラー: %w", err)
+		return "", fmt.Errorf("ダウンロードエラー: %w", err)
 	}
 	defer func() { _ = os.Remove(tmpFile) }()
 
+	if opts.sha256 != "" && sum != opts.sha256 {
+		_ = os.RemoveAll(installDir)
+		return "", fmt.Errorf("%s のチェックサムが一致しません (期待: %s, 実際: %s)", url, opts.sha256, sum)
+	}
+
 	// 展開
-	terminal.PrintlnBlue("📂 展開中...")
+	fmt.Fprintln(out, terminal.Blue("📂 展開中..."))
 	archiveType := p.ResolveArchiveType()
 	if err := m.extract(tmpFile, installDir, archiveType); err != nil {
 		_ = os.RemoveAll(installDir)
-		return fmt.Errorf("展開エラー: %w", err)
+		return "", fmt.Errorf("展開エラー: %w", err)
 	}
 
 	// インストール後コマンドを実行
 	if len(p.PostInstall) > 0 {
-		terminal.PrintlnCyan("🔧 インストール後処理を実行中...")
-		if err := m.runPostInstall(p, installDir); err != nil {
+		fmt.Fprintln(out, terminal.Cyan("🔧 インストール後処理を実行中..."))
+		if err := m.runPostInstall(p, installDir, out); err != nil {
 			_ = os.RemoveAll(installDir)
-			return fmt.Errorf("インストール後処理エラー: %w", err)
+			return "", fmt.Errorf("インストール後処理エラー: %w", err)
 		}
 	}
 
-	terminal.PrintSuccess("%s %s のインストールが完了しました", p.DisplayName, version)
-	return nil
+	if opts.quiet {
+		return sum, nil
+	}
+
+	// shims モードで使用中なら新しい実行ファイルのシムを追加
+	if err := m.refreshShims(); err != nil {
+		return "", fmt.Errorf("シム更新エラー: %w", err)
+	}
+
+	terminal.FprintSuccess(out, "%s %s のインストールが完了しました", p.DisplayName, version)
+	return sum, nil
 }
 
 // symlink を更新してツールのアクティブバージョンを切り替える
 func (m *Manager) Use(toolName, version string) error {
+	if err := m.switchVersion(toolName, version); err != nil {
+		return err
+	}
+
+	if err := m.RebuildBin(); err != nil {
+		return fmt.Errorf("bin ディレクトリ更新エラー: %w", err)
+	}
+	return nil
+}
+
+// current 配下の symlink のみを切り替える（~/.arsenal/bin は更新しない）
+func (m *Manager) switchVersion(toolName, version string) error {
 	p, err := m.registry.Get(toolName)
 	if err != nil {
 		return err
@@ -137,6 +199,16 @@ func (m *Manager) Uninstall(toolName, version string) error {
 	if err := os.RemoveAll(versionDir); err != nil {
 		return fmt.Errorf("削除エラー: %w", err)
 	}
+	if m.paths.Links != "" {
+		_ = os.RemoveAll(m.paths.ToolLinksPath(toolName, version))
+	}
+
+	if err := m.RebuildBin(); err != nil {
+		return fmt.Errorf("bin ディレクトリ更新エラー: %w", err)
+	}
+	if err := m.refreshShims(); err != nil {
+		return fmt.Errorf("シム更新エラー: %w", err)
+	}
 
 	terminal.PrintSuccess("%s %s をアンインストールしました", p.DisplayName, version)
 	return nil
@@ -210,18 +282,23 @@ func (m *Manager) Doctor() []DiagResult {
 	results = append(results, m.checkDir("Arsenal ルート", m.paths.Root))
 	results = append(results, m.checkDir("バージョンディレクトリ", m.paths.Versions))
 	results = append(results, m.checkDir("カレントディレクトリ", m.paths.Current))
+	results = append(results, m.checkBinDir())
 
 	// PATH をチェック
 	results = append(results, m.checkPATH())
 
+	// プラグイン定義をチェック
+	results = append(results, m.checkPlugins()...)
+
 	// インストール済みツールをチェック
 	currentAll, _ := m.CurrentAll()
-	for tool, ver := range currentAll {
-		results = append(results, DiagResult{
-			Name:    fmt.Sprintf("%s バージョン", tool),
-			Status:  StatusOK,
-			Message: ver,
-		})
+	tools := make([]string, 0, len(currentAll))
+	for tool := range currentAll {
+		tools = append(tools, tool)
+	}
+	sort.Strings(tools)
+	for _, tool := range tools {
+		results = append(results, m.checkToolBins(tool, currentAll[tool]))
 	}
 
 	return results
@@ -250,43 +327,164 @@ func (m *Manager) checkDir(name, path string) DiagResult {
 	return DiagResult{Name: name, Status: StatusOK, Message: path}
 }
 
+// ~/.arsenal/bin がリンク切れの symlink を含んでいないかチェックする
+func (m *Manager) checkBinDir() DiagResult {
+	name := "bin ディレクトリ"
+
+	entries, err := os.ReadDir(m.paths.Bin)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return DiagResult{Name: name, Status: StatusWarn, Message: "見つかりません ('arsenal use' で作成されます)"}
+		}
+		return DiagResult{Name: name, Status: StatusError, Message: err.Error()}
+	}
+
+	var broken []string
+	for _, e := range entries {
+		if _, err := os.Stat(filepath.Join(m.paths.Bin, e.Name())); err != nil {
+			broken = append(broken, e.Name())
+		}
+	}
+	if len(broken) > 0 {
+		return DiagResult{
+			Name:    name,
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("リンク切れ: %s ('arsenal use' で再構築)", strings.Join(broken, ", ")),
+		}
+	}
+
+	return DiagResult{Name: name, Status: StatusOK, Message: fmt.Sprintf("%s (%d 個の実行ファイル)", m.paths.Bin, len(entries))}
+}
+
+// ~/.arsenal/bin（shims モードでは ~/.arsenal/shims）が PATH のエントリとして含まれているかチェックする
 func (m *Manager) checkPATH() DiagResult {
-	pathEnv := os.Getenv("PATH")
-	currentDir := m.paths.Current
-	if strings.Contains(pathEnv, currentDir) {
-		return DiagResult{Name: "PATH", Status: StatusOK, Message: "arsenal/current が PATH に含まれています"}
+	for _, entry := range filepath.SplitList(os.Getenv("PATH")) {
+		if entry == "" {
+			continue
+		}
+		for _, dir := range []string{m.paths.Bin, m.paths.Shims} {
+			if dir != "" && filepath.Clean(entry) == filepath.Clean(dir) {
+				return DiagResult{Name: "PATH", Status: StatusOK, Message: fmt.Sprintf("%s が PATH に含まれています", dir)}
+			}
+		}
 	}
 	return DiagResult{
 		Name:    "PATH",
 		Status:  StatusWarn,
-		Message: fmt.Sprintf("PATH に追加: export PATH=\"%s/**/bin:$PATH\" ('arsenal init-shell' 参照)", currentDir),
+		Message: fmt.Sprintf("PATH に追加: export PATH=\"%s:$PATH\" ('arsenal init-shell' 参照)", m.paths.Bin),
+	}
+}
+
+// プラグイン定義の読み込みエラーと組み込みプラグインの上書きをチェックする
+func (m *Manager) checkPlugins() []DiagResult {
+	var results []DiagResult
+
+	for _, loadErr := range m.registry.LoadErrors() {
+		results = append(results, DiagResult{
+			Name:    "プラグイン定義",
+			Status:  StatusError,
+			Message: loadErr.Error(),
+		})
+	}
+
+	overrides := m.registry.BuiltinOverrides()
+	names := make([]string, 0, len(overrides))
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		results = append(results, DiagResult{
+			Name:    fmt.Sprintf("%s プラグイン", name),
+			Status:  StatusWarn,
+			Message: fmt.Sprintf("組み込み定義が %s で上書きされています", overrides[name].Path),
+		})
+	}
+
+	if len(results) == 0 {
+		results = append(results, DiagResult{
+			Name:    "プラグイン定義",
+			Status:  StatusOK,
+			Message: fmt.Sprintf("%d 個のプラグインを読み込みました", len(m.registry.List())),
+		})
+	}
+
+	return results
+}
+
+// アクティブバージョンの bin ディレクトリと公開する実行ファイルをチェックする
+func (m *Manager) checkToolBins(tool, ver string) DiagResult {
+	name := fmt.Sprintf("%s バージョン", tool)
+
+	p, err := m.registry.Get(tool)
+	if err != nil {
+		return DiagResult{Name: name, Status: StatusWarn, Message: fmt.Sprintf("%s (プラグインが見つかりません)", ver)}
+	}
+
+	for _, dir := range m.VersionBinDirs(tool, ver) {
+		if _, err := os.Stat(dir); err != nil {
+			return DiagResult{Name: name, Status: StatusWarn, Message: fmt.Sprintf("%s (bin ディレクトリがありません: %s)", ver, dir)}
+		}
+	}
+
+	exes, err := m.Executables(tool, ver)
+	if err != nil {
+		return DiagResult{Name: name, Status: StatusWarn, Message: fmt.Sprintf("%s (実行ファイル取得エラー: %v)", ver, err)}
 	}
+
+	found := make(map[string]bool, len(exes))
+	for _, e := range exes {
+		found[e.Name] = true
+		found[strings.TrimSuffix(e.Name, filepath.Ext(e.Name))] = true
+	}
+
+	var missing []string
+	for _, e := range p.Executables {
+		if !found[e] {
+			missing = append(missing, e)
+		}
+	}
+	if len(missing) > 0 {
+		return DiagResult{Name: name, Status: StatusWarn, Message: fmt.Sprintf("%s (実行ファイルが見つかりません: %s)", ver, strings.Join(missing, ", "))}
+	}
+
+	return DiagResult{Name: name, Status: StatusOK, Message: fmt.Sprintf("%s (実行ファイル %d 個)", ver, len(exes))}
+}
+
+// URL から一時ファイルにダウンロードし、ファイルの SHA-256 を返す
+func (m *Manager) download(url string) (string, string, error) {
+	return m.fetch(url, os.Stdout)
 }
 
-// URL から一時ファイルにダウンロードする
-func (m *Manager) download(url string) (string, error) {
+// URL から一時ファイルにダウンロードし、進捗を progress に書き出す（nil なら表示しない）
+func (m *Manager) fetch(url string, progress io.Writer) (string, string, error) {
 	resp, err := http.Get(url)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
+		return "", "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
 	}
 
 	tmpFile, err := os.CreateTemp("", "arsenal-download-*")
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 	defer func() { _ = tmpFile.Close() }()
 
+	// 書き込みと同時にハッシュを計算
+	h := sha256.New()
+	dest := io.MultiWriter(tmpFile, h)
+
 	// Content-Length から総ファイルサイズを取得
 	totalSize := resp.ContentLength
 
 	// プログレスバー付きでダウンロード
-	if totalSize > 0 {
+	if progress != nil && totalSize > 0 {
 		pw := &progressWriter{
+			out:       progress,
 			total:     totalSize,
 			startTime: time.Now(),
 		}
@@ -307,27 +505,28 @@ func (m *Manager) download(url string) (string, error) {
 			}
 		}()
 
-		if _, err := io.Copy(tmpFile, reader); err != nil {
+		if _, err := io.Copy(dest, reader); err != nil {
 			done <- true
 			_ = os.Remove(tmpFile.Name())
-			return "", err
+			return "", "", err
 		}
 
 		done <- true
 		pw.printComplete()
 	} else {
-		// Content-Length がない場合は通常のコピー
-		if _, err := io.Copy(tmpFile, resp.Body); err != nil {
+		// 進捗を表示しない場合や Content-Length がない場合は通常のコピー
+		if _, err := io.Copy(dest, resp.Body); err != nil {
 			_ = os.Remove(tmpFile.Name())
-			return "", err
+			return "", "", err
 		}
 	}
 
-	return tmpFile.Name(), nil
+	return tmpFile.Name(), hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // プログレスバー用のライター
 type progressWriter struct {
+	out       io.Writer
 	total     int64
 	current   int64
 	startTime time.Time
@@ -357,7 +556,7 @@ func (pw *progressWriter) printProgress() {
 	totalMB := float64(total) / (1024 * 1024)
 
 	// 同じ行を上書き
-	fmt.Printf("\r   \x1b[36mダウンロード中... %.1f MB / %.1f MB (%.0f%%)\x1b[0m", currentMB, totalMB, percent)
+	fmt.Fprintf(pw.out, "\r   \x1b[36mダウンロード中... %.1f MB / %.1f MB (%.0f%%)\x1b[0m", currentMB, totalMB, percent)
 }
 
 func (pw *progressWriter) printComplete() {
@@ -366,7 +565,7 @@ func (pw *progressWriter) printComplete() {
 	pw.mu.Unlock()
 
 	totalMB := float64(total) / (1024 * 1024)
-	fmt.Printf("\r   \x1b[32mダウンロード完了 (%.1f MB)\x1b[0m\n", totalMB)
+	fmt.Fprintf(pw.out, "\r   \x1b[32mダウンロード完了 (%.1f MB)\x1b[0m\n", totalMB)
 }
 
 // アーカイブを対象ディレクトリに展開する
@@ -501,10 +700,10 @@ func (m *Manager) extractZip(archivePath, targetDir string) error {
 }
 
 // インストール後コマンドを実行する
-func (m *Manager) runPostInstall(p *plugin.Plugin, installDir string) error {
+func (m *Manager) runPostInstall(p *plugin.Plugin, installDir string, out io.Writer) error {
 	// TODO: インストール後コマンド実行を実装
 	// os/exec を使ってインストールディレクトリでコマンドを実行
-	terminal.PrintWarning("インストール後コマンドはまだ実装されていません")
+	terminal.FprintWarning(out, "インストール後コマンドはまだ実装されていません")
 	return nil
 }
 
@@ -0,0 +1,36 @@
+package version
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// InstallWithOutput がインストールの進捗を指定した出力先に書き出すかテストする
+func TestManagerInstallWithOutput(t *testing.T) {
+	// テスト用の HTTP サーバーを起動（空の tar.gz を返す）
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(emptyTarGz)
+	}))
+	defer server.Close()
+
+	m, _ := newTestManager(t, map[string]string{"testnode.toml": `name = "testnode"
+display_name = "Test Node.js"
+download_url = "` + server.URL + `/node-v{{version}}.tar.gz"
+archive_type = "tar.gz"
+`})
+
+	var out strings.Builder
+	if err := m.InstallWithOutput("testnode", "20.10.0", &out); err != nil {
+		t.Fatalf("InstallWithOutput() エラー: %v", err)
+	}
+	if !m.isInstalled("testnode", "20.10.0") {
+		t.Error("インストールされていません")
+	}
+	for _, want := range []string{"Test Node.js 20.10.0 をダウンロード中", "インストールが完了しました"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("出力に %q が含まれていません:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package version
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// バージョンの決定元
+type VersionSource string
+
+const (
+	SourceEnv          VersionSource = "env"          // ARSENAL_<TOOL>_VERSION 環境変数
+	SourceToolVersions VersionSource = "toolversions" // .toolversions（上位ディレクトリの指定を統合したもの）
+	SourceGlobal       VersionSource = "global"       // ~/.arsenal/current の symlink
+	SourceGlobalFile   VersionSource = "global-file"  // ~/.arsenal/toolversions（どこにも指定がない場合のデフォルト）
+	SourceExec         VersionSource = "exec"         // arsenal exec の引数
+)
+
+// ツール 1 件のバージョン解決結果を表す
+type Resolution struct {
+	Tool      string
+	Version   string
+	Source    VersionSource
+	Origin    string // 決定元のファイルまたは symlink のパス
+	Installed bool
+	// .toolversions で複数のバージョンが指定された場合の、Version 以外のインストール済みバージョン（優先順）
+	// PATH では Version の後ろに追加される
+	Fallbacks []string
+}
+
+// ツールのバージョンを上書きする環境変数名を返す
+// 例: node -> ARSENAL_NODE_VERSION, node-lts -> ARSENAL_NODE_LTS_VERSION
+func EnvOverrideVar(toolName string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, strings.ToUpper(toolName))
+	return "ARSENAL_" + name + "_VERSION"
+}
+
+// dir で有効になる各ツールのバージョンをツール名順で返す
+// 優先順位: 環境変数 > .toolversions（近いディレクトリほど優先）> グローバルの current > ~/.arsenal/toolversions
+// 環境変数は arsenal shell がプロジェクト内でも現在のシェルだけ切り替えるため、.toolversions より優先する
+// .toolversions が読めない場合もグローバルの解決結果はエラーとともに返す
+func (m *Manager) Resolve(dir string) ([]Resolution, error) {
+	resolved := make(map[string]Resolution)
+
+	// グローバルのデフォルトは読めなくても解決を続ける（sync --global でエラーになる）
+	if global, err := m.ReadGlobalToolVersions(nil); err == nil {
+		for tool, versions := range global.Tools {
+			ver, fallbacks := m.selectVersion(tool, versions, &LockFile{})
+			resolved[tool] = Resolution{
+				Tool:      tool,
+				Version:   ver,
+				Source:    SourceGlobalFile,
+				Origin:    global.Origins[tool],
+				Fallbacks: fallbacks,
+			}
+		}
+	}
+
+	currentAll, err := m.CurrentAll()
+	if err != nil {
+		return nil, err
+	}
+	for tool, ver := range currentAll {
+		resolved[tool] = Resolution{
+			Tool:    tool,
+			Version: ver,
+			Source:  SourceGlobal,
+			Origin:  m.paths.ToolCurrentPath(tool),
+		}
+	}
+
+	tv, tvErr := m.ReadToolVersions(dir, nil)
+	if errors.Is(tvErr, ErrToolVersionsNotFound) {
+		tvErr = nil
+	}
+	if tv != nil {
+		// ロックファイルが読めなくても解決は続ける（sync でエラーになる）
+		lock, err := LoadLockFile(tv.LockPath())
+		if err != nil {
+			lock = &LockFile{}
+		}
+		for tool, versions := range tv.Tools {
+			// .tool-versions に並ぶ arsenal が扱わないツールは表示や環境変数の対象にしない
+			if m.isUnknownAsdfTool(tv, tool) {
+				continue
+			}
+			ver, fallbacks := m.selectVersion(tool, versions, lock)
+			resolved[tool] = Resolution{
+				Tool:      tool,
+				Version:   ver,
+				Source:    SourceToolVersions,
+				Origin:    tv.Origins[tool],
+				Fallbacks: fallbacks,
+			}
+		}
+	}
+
+	for _, tool := range m.registry.List() {
+		name := EnvOverrideVar(tool)
+		if ver := os.Getenv(name); ver != "" {
+			resolved[tool] = Resolution{
+				Tool:    tool,
+				Version: ver,
+				Source:  SourceEnv,
+				Origin:  name,
+			}
+		}
+	}
+
+	result := make([]Resolution, 0, len(resolved))
+	for _, r := range resolved {
+		r.Installed = m.isInstalled(r.Tool, r.Version)
+		result = append(result, r)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tool < result[j].Tool })
+
+	return result, tvErr
+}
+
+// dir で有効になる単一ツールのバージョンを返す
+// どこにも指定がなければ Version が空の結果を返す
+func (m *Manager) ResolveTool(dir, toolName string) (Resolution, error) {
+	resolutions, err := m.Resolve(dir)
+	for _, r := range resolutions {
+		if r.Tool == toolName {
+			return r, err
+		}
+	}
+	return Resolution{Tool: toolName}, err
+}
+
+// 優先順のバージョン指定から使うバージョンを選ぶ
+// 先頭から探して最初のインストール済みバージョン（なければ先頭）と、残りのインストール済みバージョンを返す
+func (m *Manager) selectVersion(toolName string, specs []string, lock *LockFile) (string, []string) {
+	selected := ""
+	var fallbacks []string
+	for _, spec := range specs {
+		v := m.installedVersionFor(toolName, spec, lock)
+		if v == "" {
+			continue
+		}
+		if selected == "" {
+			selected = v
+		} else if v != selected {
+			fallbacks = append(fallbacks, v)
+		}
+	}
+
+	if selected == "" && len(specs) > 0 {
+		selected = specs[0]
+		if locked := lock.Find(toolName, specs[0]); locked != nil {
+			selected = locked.Version
+		}
+	}
+	return selected, fallbacks
+}
+
+// バージョン指定に対応するインストール済みのバージョンを返す（なければ空）
+// 完全に一致するもの、ロックされたもの、一致する最新のものの順に探す
+func (m *Manager) installedVersionFor(toolName, spec string, lock *LockFile) string {
+	if m.isInstalled(toolName, spec) {
+		return spec
+	}
+	if locked := lock.Find(toolName, spec); locked != nil && m.isInstalled(toolName, locked.Version) {
+		return locked.Version
+	}
+	ver, _ := m.FindInstalled(toolName, spec)
+	return ver
+}
+
+// グローバル以外で決まったインストール済みバージョンの bin ディレクトリを返す
+// シェルの PATH の先頭に追加する順序（ツール名順、各ツールはフォールバックを後ろに）で並ぶ
+func (m *Manager) PinnedBinDirs(resolutions []Resolution) []string {
+	var dirs []string
+	for _, r := range resolutions {
+		if r.Source == SourceGlobal || !r.Installed {
+			continue
+		}
+		dirs = append(dirs, m.resolutionBinDirs(r)...)
+	}
+	return dirs
+}
+
+// 解決されたインストール済みの全バージョンの bin ディレクトリを優先順に返す
+func (m *Manager) ResolvedBinDirs(resolutions []Resolution) []string {
+	var dirs []string
+	for _, r := range resolutions {
+		if r.Installed {
+			dirs = append(dirs, m.resolutionBinDirs(r)...)
+		}
+	}
+	return dirs
+}
+
+// 解決されたバージョンとフォールバックの bin ディレクトリを優先順に返す
+// executables 許可リストのあるツールは許可された実行ファイルだけを含むディレクトリを返す
+// ディレクトリを作れないバージョンは PATH に追加しない
+func (m *Manager) resolutionBinDirs(r Resolution) []string {
+	var dirs []string
+	for _, v := range append([]string{r.Version}, r.Fallbacks...) {
+		exposed, err := m.ExposedBinDirs(r.Tool, v)
+		if err != nil {
+			continue
+		}
+		dirs = append(dirs, exposed...)
+	}
+	return dirs
+}
+
+// 解決されたバージョンから実行ファイルを探し、なければフォールバックのバージョンから探す
+// 見つかった実行ファイルの絶対パスとそのバージョンを返す
+func (m *Manager) FindResolvedExecutable(r Resolution, name string) (string, string, error) {
+	for _, v := range append([]string{r.Version}, r.Fallbacks...) {
+		if path := m.findExecutable(r.Tool, v, name); path != "" {
+			return path, v, nil
+		}
+	}
+	return "", "", fmt.Errorf("%s は %s %s にありません", name, r.Tool, r.Version)
+}
+
+// 解決されたインストール済みバージョンのプラグイン env_vars を返す
+// グローバル以外で決まったバージョンでは current_dir もバージョンディレクトリを指す
+func (m *Manager) ResolvedEnvVars(resolutions []Resolution) []EnvVar {
+	var result []EnvVar
+	for _, r := range resolutions {
+		if !r.Installed {
+			continue
+		}
+		if r.Source == SourceGlobal {
+			result = append(result, m.ToolEnvVars(r.Tool, r.Version)...)
+			continue
+		}
+		result = append(result, m.toolEnvVars(r.Tool, r.Version, m.paths.ToolVersionPath(r.Tool, r.Version))...)
+	}
+	return result
+}
+
+// バージョンディレクトリが存在するか判定する
+func (m *Manager) isInstalled(toolName, version string) bool {
+	info, err := os.Stat(m.paths.ToolVersionPath(toolName, version))
+	return err == nil && info.IsDir()
+}
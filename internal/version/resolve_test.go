@@ -0,0 +1,284 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+)
+
+// .toolversions の指定がグローバルより優先されるかテストする
+func TestManagerResolve(t *testing.T) {
+	m, paths := newTestManager(t, map[string]string{
+		"testgo.toml": `name = "testgo"
+
+[env_vars]
+GOROOT = "{{current_dir}}"
+`,
+	})
+
+	// グローバルは testgo 1.21.0 と node 20.10.0
+	for _, tv := range [][2]string{{"testgo", "1.21.0"}, {"testgo", "1.22.0"}, {"node", "20.10.0"}} {
+		if err := os.MkdirAll(paths.ToolVersionPath(tv[0], tv[1]), 0755); err != nil {
+			t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+		}
+	}
+	if err := m.switchVersion("testgo", "1.21.0"); err != nil {
+		t.Fatalf("switchVersion() エラー: %v", err)
+	}
+	if err := m.switchVersion("node", "20.10.0"); err != nil {
+		t.Fatalf("switchVersion() エラー: %v", err)
+	}
+
+	// プロジェクトでは testgo 1.22.0 と未インストールの node 18.19.0 を固定
+	projectDir := t.TempDir()
+	tvPath := filepath.Join(projectDir, config.ToolVersionFile)
+	if err := os.WriteFile(tvPath, []byte("testgo 1.22.0\nnode 18.19.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+	subDir := filepath.Join(projectDir, "src")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	resolutions, err := m.Resolve(subDir)
+	if err != nil {
+		t.Fatalf("Resolve() エラー: %v", err)
+	}
+
+	expected := []Resolution{
+		{Tool: "node", Version: "18.19.0", Source: SourceToolVersions, Origin: tvPath, Installed: false},
+		{Tool: "testgo", Version: "1.22.0", Source: SourceToolVersions, Origin: tvPath, Installed: true},
+	}
+	if len(resolutions) != len(expected) {
+		t.Fatalf("Resolve() = %+v, want %+v", resolutions, expected)
+	}
+	for i, want := range expected {
+		if !reflect.DeepEqual(resolutions[i], want) {
+			t.Errorf("resolutions[%d] = %+v, want %+v", i, resolutions[i], want)
+		}
+	}
+
+	// 未インストールの node は PATH に追加されない
+	binDirs := m.PinnedBinDirs(resolutions)
+	if len(binDirs) != 1 || binDirs[0] != filepath.Join(paths.ToolVersionPath("testgo", "1.22.0"), "bin") {
+		t.Errorf("PinnedBinDirs() = %v", binDirs)
+	}
+
+	// 固定されたバージョンの current_dir はバージョンディレクトリを指す
+	vars := m.ResolvedEnvVars(resolutions)
+	if len(vars) != 1 || vars[0].Value != paths.ToolVersionPath("testgo", "1.22.0") {
+		t.Errorf("ResolvedEnvVars() = %+v", vars)
+	}
+
+	// プロジェクト外ではグローバルのバージョンになり、PATH には何も追加しない
+	resolutions, err = m.Resolve(t.TempDir())
+	if err != nil {
+		t.Fatalf("Resolve() エラー: %v", err)
+	}
+	if len(resolutions) != 2 || resolutions[1].Version != "1.21.0" || resolutions[1].Source != SourceGlobal {
+		t.Errorf("Resolve() = %+v", resolutions)
+	}
+	if dirs := m.PinnedBinDirs(resolutions); len(dirs) != 0 {
+		t.Errorf("PinnedBinDirs() = %v, want empty", dirs)
+	}
+}
+
+// ~/.arsenal/toolversions がどこにも指定がないツールだけに使われるかテストする
+func TestManagerResolveGlobalFile(t *testing.T) {
+	m, paths := newTestManager(t, map[string]string{"testgo.toml": `name = "testgo"`})
+
+	for _, tv := range [][2]string{{"node", "18.19.0"}, {"node", "20.10.0"}, {"testgo", "1.21.0"}, {"testgo", "1.22.0"}} {
+		if err := os.MkdirAll(paths.ToolVersionPath(tv[0], tv[1]), 0755); err != nil {
+			t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+		}
+	}
+	if err := m.switchVersion("node", "18.19.0"); err != nil {
+		t.Fatalf("switchVersion() エラー: %v", err)
+	}
+
+	globalPath := paths.GlobalToolVersionsPath()
+	if err := os.WriteFile(globalPath, []byte("node 20.10.0\ntestgo 1.22.0\n"), 0644); err != nil {
+		t.Fatalf("グローバルのデフォルト作成エラー: %v", err)
+	}
+
+	// グローバルの current が優先され、current のない testgo はデフォルトを使う
+	resolutions, err := m.Resolve(t.TempDir())
+	if err != nil {
+		t.Fatalf("Resolve() エラー: %v", err)
+	}
+	expected := []Resolution{
+		{Tool: "node", Version: "18.19.0", Source: SourceGlobal, Origin: paths.ToolCurrentPath("node"), Installed: true},
+		{Tool: "testgo", Version: "1.22.0", Source: SourceGlobalFile, Origin: globalPath, Installed: true},
+	}
+	if !reflect.DeepEqual(resolutions, expected) {
+		t.Errorf("Resolve() = %+v, want %+v", resolutions, expected)
+	}
+
+	// .toolversions の指定はデフォルトより優先される
+	projectDir := t.TempDir()
+	tvPath := filepath.Join(projectDir, config.ToolVersionFile)
+	if err := os.WriteFile(tvPath, []byte("testgo 1.21.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+	r, err := m.ResolveTool(projectDir, "testgo")
+	if err != nil {
+		t.Fatalf("ResolveTool() エラー: %v", err)
+	}
+	if r.Version != "1.21.0" || r.Source != SourceToolVersions {
+		t.Errorf("ResolveTool() = %+v, want 1.21.0 (.toolversions)", r)
+	}
+}
+
+// 壊れた .toolversions でもグローバルの解決結果が返るかテストする
+func TestManagerResolveBrokenToolVersions(t *testing.T) {
+	m, paths := newTestManager(t, nil)
+
+	if err := os.MkdirAll(paths.ToolVersionPath("node", "20.10.0"), 0755); err != nil {
+		t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+	}
+	if err := m.switchVersion("node", "20.10.0"); err != nil {
+		t.Fatalf("switchVersion() エラー: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("node\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	resolutions, err := m.Resolve(projectDir)
+	if err == nil {
+		t.Error("壊れた .toolversions でエラーが返されませんでした")
+	}
+	if len(resolutions) != 1 || resolutions[0].Source != SourceGlobal {
+		t.Errorf("Resolve() = %+v", resolutions)
+	}
+}
+
+// .tool-versions の対応するプラグインのないツールが解決結果に含まれないかテストする
+func TestManagerResolveSkipsUnknownAsdfTools(t *testing.T) {
+	m, _ := newTestManager(t, nil)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.AsdfToolVersionFile), []byte("ruby 3.3.0\nnodejs 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".tool-versions 作成エラー: %v", err)
+	}
+
+	resolutions, err := m.Resolve(projectDir)
+	if err != nil {
+		t.Fatalf("Resolve() エラー: %v", err)
+	}
+	if len(resolutions) != 1 || resolutions[0].Tool != "node" || resolutions[0].Version != "20.10.0" {
+		t.Errorf("Resolve() = %+v", resolutions)
+	}
+}
+
+// 環境変数による上書きが .toolversions より優先されるかテストする
+func TestManagerResolveEnvOverride(t *testing.T) {
+	m, _ := newTestManager(t, nil)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("node 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	t.Setenv("ARSENAL_NODE_VERSION", "18.19.0")
+
+	r, err := m.ResolveTool(projectDir, "node")
+	if err != nil {
+		t.Fatalf("ResolveTool() エラー: %v", err)
+	}
+	if r.Version != "18.19.0" || r.Source != SourceEnv || r.Origin != "ARSENAL_NODE_VERSION" {
+		t.Errorf("ResolveTool() = %+v", r)
+	}
+
+	// どこにも指定がないツールは空のバージョンを返す
+	r, err = m.ResolveTool(projectDir, "unknown")
+	if err != nil {
+		t.Fatalf("ResolveTool() エラー: %v", err)
+	}
+	if r.Version != "" {
+		t.Errorf("ResolveTool(unknown) = %+v, want empty version", r)
+	}
+}
+
+// ツール名から上書き用の環境変数名が作られるかテストする
+func TestEnvOverrideVar(t *testing.T) {
+	tests := []struct {
+		tool string
+		want string
+	}{
+		{"node", "ARSENAL_NODE_VERSION"},
+		{"node-lts", "ARSENAL_NODE_LTS_VERSION"},
+		{"python3.12", "ARSENAL_PYTHON3_12_VERSION"},
+	}
+
+	for _, tt := range tests {
+		if got := EnvOverrideVar(tt.tool); got != tt.want {
+			t.Errorf("EnvOverrideVar(%q) = %q, want %q", tt.tool, got, tt.want)
+		}
+	}
+}
+
+// 1 行に複数のバージョンがある場合に最初のインストール済みバージョンと残りが返るかテストする
+func TestManagerResolveFallbacks(t *testing.T) {
+	m, paths := newTestManager(t, nil)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("node 22.0.0 20.10.0 18.19.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		installed     []string
+		wantVersion   string
+		wantInstalled bool
+		wantFallbacks []string
+	}{
+		{"未インストール", nil, "22.0.0", false, nil},
+		{"先頭が未インストール", []string{"18.19.0"}, "18.19.0", true, nil},
+		{"複数インストール済み", []string{"20.10.0", "22.0.0"}, "22.0.0", true, []string{"20.10.0", "18.19.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range tt.installed {
+				if err := os.MkdirAll(filepath.Join(paths.ToolVersionPath("node", v), "bin"), 0755); err != nil {
+					t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+				}
+			}
+
+			r, err := m.ResolveTool(projectDir, "node")
+			if err != nil {
+				t.Fatalf("ResolveTool() エラー: %v", err)
+			}
+			if r.Version != tt.wantVersion || r.Installed != tt.wantInstalled || !reflect.DeepEqual(r.Fallbacks, tt.wantFallbacks) {
+				t.Errorf("ResolveTool() = %+v", r)
+			}
+		})
+	}
+
+	// フォールバックの bin ディレクトリは優先順で PATH に並ぶ
+	r, _ := m.ResolveTool(projectDir, "node")
+	want := []string{
+		filepath.Join(paths.ToolVersionPath("node", "22.0.0"), "bin"),
+		filepath.Join(paths.ToolVersionPath("node", "20.10.0"), "bin"),
+		filepath.Join(paths.ToolVersionPath("node", "18.19.0"), "bin"),
+	}
+	if got := m.PinnedBinDirs([]Resolution{r}); !reflect.DeepEqual(got, want) {
+		t.Errorf("PinnedBinDirs() = %v, want %v", got, want)
+	}
+
+	// 先のバージョンにない実行ファイルは後ろのバージョンから見つかる
+	if runtime.GOOS != "windows" {
+		writeExecutable(t, filepath.Join(paths.ToolVersionPath("node", "20.10.0"), "bin", "corepack"))
+		path, ver, err := m.FindResolvedExecutable(r, "corepack")
+		if err != nil || ver != "20.10.0" || filepath.Base(path) != "corepack" {
+			t.Errorf("FindResolvedExecutable() = %q, %q, %v", path, ver, err)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/arsenal/internal/terminal"
+)
+
+// ~/.arsenal/shims をインストール済みの全バージョンが公開する実行ファイルのシムで作り直す
+// シムは実行時に shim-exec でバージョンを解決するため、バージョンの切り替えでは再生成不要
+// 生成したシムの実行ファイル名を名前順で返す
+func (m *Manager) Reshim() ([]string, error) {
+	shimDir := m.paths.Shims
+	if shimDir == "" {
+		return nil, nil
+	}
+
+	arsenalPath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("実行ファイルのパス取得エラー: %w", err)
+	}
+
+	if err := os.MkdirAll(shimDir, 0755); err != nil {
+		return nil, err
+	}
+
+	// シムディレクトリは Arsenal 専用のため、既存のエントリは全て削除する
+	entries, err := os.ReadDir(shimDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(shimDir, e.Name())); err != nil {
+			return nil, err
+		}
+	}
+
+	tools := m.registry.List()
+	sort.Strings(tools)
+
+	owners := make(map[string]string)
+	for _, tool := range tools {
+		versions, err := m.List(tool)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ver := range versions {
+			exes, err := m.Executables(tool, ver)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, exe := range exes {
+				owner, ok := owners[exe.Name]
+				if ok {
+					if owner != tool {
+						terminal.PrintWarning("%s は %s と %s の両方にあります (%s を使用)", exe.Name, owner, tool, owner)
+					}
+					continue
+				}
+				owners[exe.Name] = tool
+			}
+		}
+	}
+
+	names := make([]string, 0, len(owners))
+	for name := range owners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file, content := shimScript(arsenalPath, owners[name], name)
+		if err := os.WriteFile(filepath.Join(shimDir, file), []byte(content), 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	return names, nil
+}
+
+// シムディレクトリが作成済み（shims モードで使用中）の場合のみシムを作り直す
+func (m *Manager) refreshShims() error {
+	if m.paths.Shims == "" {
+		return nil
+	}
+	if _, err := os.Stat(m.paths.Shims); err != nil {
+		return nil
+	}
+
+	_, err := m.Reshim()
+	return err
+}
+
+// 指定バージョンの bin ディレクトリから実行ファイルを探して絶対パスを返す
+func (m *Manager) FindExecutable(toolName, version, name string) (string, error) {
+	if path := m.findExecutable(toolName, version, name); path != "" {
+		return path, nil
+	}
+	return "", fmt.Errorf("%s は %s %s にありません", name, toolName, version)
+}
+
+// シムのファイル名と内容を返す
+func shimScript(arsenalPath, toolName, name string) (string, string) {
+	if runtime.GOOS == "windows" {
+		file := strings.TrimSuffix(name, filepath.Ext(name)) + ".cmd"
+		return file, fmt.Sprintf("@echo off\r\nrem arsenal shim (tool: %s)\r\n\"%s\" shim-exec \"%s\" \"%s\" %%*\r\n",
+			toolName, arsenalPath, toolName, name)
+	}
+
+	return name, fmt.Sprintf("#!/bin/sh\n# arsenal shim (tool: %s) - 'arsenal reshim' で再生成されます\nexec %s shim-exec %s %s \"$@\"\n",
+		toolName, ShellQuote(arsenalPath), ShellQuote(toolName), ShellQuote(name))
+}
+
+// POSIX シェル用にシングルクォートで囲む
+// シェルのスクリプトやシェルコードを生成するコマンドで共通に使う
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
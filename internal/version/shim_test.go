@@ -0,0 +1,109 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// インストール済みの全バージョンの実行ファイルにシムが生成されるかテストする
+func TestReshim(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, map[string]string{"testrust.toml": multiBinPlugin})
+	paths.Shims = filepath.Join(paths.Root, "shims")
+
+	// 古いバージョンにのみ rustc がある
+	writeExecutable(t, filepath.Join(paths.ToolVersionPath("testrust", "1.74.0"), "rustc", "bin", "rustc"))
+	writeExecutable(t, filepath.Join(paths.ToolVersionPath("testrust", "1.75.0"), "cargo", "bin", "cargo"))
+
+	// 前回のシムは削除される
+	writeExecutable(t, filepath.Join(paths.Shims, "stale"))
+
+	names, err := m.Reshim()
+	if err != nil {
+		t.Fatalf("Reshim() エラー: %v", err)
+	}
+	if strings.Join(names, ",") != "cargo,rustc" {
+		t.Errorf("Reshim() = %v, want [cargo rustc]", names)
+	}
+
+	content, err := os.ReadFile(filepath.Join(paths.Shims, "cargo"))
+	if err != nil {
+		t.Fatalf("シム読み込みエラー: %v", err)
+	}
+	if !strings.Contains(string(content), "shim-exec 'testrust' 'cargo' \"$@\"") {
+		t.Errorf("シムの内容が不正です:\n%s", content)
+	}
+	if !isExecutable(filepath.Join(paths.Shims, "cargo")) {
+		t.Error("シムに実行権限がありません")
+	}
+	if _, err := os.Stat(filepath.Join(paths.Shims, "stale")); !os.IsNotExist(err) {
+		t.Error("古いシムが残っています")
+	}
+
+	// アンインストールするとシムも更新される
+	if err := m.Uninstall("testrust", "1.74.0"); err != nil {
+		t.Fatalf("Uninstall() エラー: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(paths.Shims, "rustc")); !os.IsNotExist(err) {
+		t.Error("アンインストール後も rustc のシムが残っています")
+	}
+}
+
+// シムディレクトリがなければ refreshShims が何もしないかテストする
+func TestRefreshShimsWithoutDir(t *testing.T) {
+	m, paths := newTestManager(t, nil)
+	paths.Shims = filepath.Join(paths.Root, "shims")
+
+	if err := m.refreshShims(); err != nil {
+		t.Fatalf("refreshShims() エラー: %v", err)
+	}
+	if _, err := os.Stat(paths.Shims); !os.IsNotExist(err) {
+		t.Error("shims モードでないのにシムディレクトリが作成されました")
+	}
+}
+
+// 指定バージョンの bin ディレクトリから実行ファイルを探せるかテストする
+func TestFindExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, map[string]string{"testrust.toml": multiBinPlugin})
+	rustc := filepath.Join(paths.ToolVersionPath("testrust", "1.75.0"), "rustc", "bin", "rustc")
+	writeExecutable(t, rustc)
+
+	got, err := m.FindExecutable("testrust", "1.75.0", "rustc")
+	if err != nil {
+		t.Fatalf("FindExecutable() エラー: %v", err)
+	}
+	if got != rustc {
+		t.Errorf("FindExecutable() = %q, want %q", got, rustc)
+	}
+
+	if _, err := m.FindExecutable("testrust", "1.75.0", "cargo"); err == nil {
+		t.Error("存在しない実行ファイルでエラーが返されませんでした")
+	}
+}
+
+// シングルクォートが正しくエスケープされるかテストする
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"/opt/java", "'/opt/java'"},
+		{"it's", `'it'\''s'`},
+	}
+
+	for _, tt := range tests {
+		if got := ShellQuote(tt.in); got != tt.want {
+			t.Errorf("ShellQuote(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// バージョン指定がバージョンに一致するか判定する
+// "1.21" は "1.21" 自身と "1.21.x" に一致する（"1.210" には一致しない）
+func MatchesVersionSpec(spec, version string) bool {
+	return version == spec || strings.HasPrefix(version, spec+".")
+}
+
+// インストール済みバージョンから指定に一致する最新のものを返す
+// 一致するものがなければ空文字列を返す
+func (m *Manager) FindInstalled(toolName, spec string) (string, error) {
+	versions, err := m.List(toolName)
+	if err != nil {
+		return "", err
+	}
+	return latestMatching(versions, spec), nil
+}
+
+// リモートのバージョン一覧から指定に一致する最新のものを返す
+func (m *Manager) FindRemote(toolName, spec string) (string, error) {
+	remote, err := m.ListRemote(toolName, 0)
+	if err != nil {
+		return "", err
+	}
+
+	versions := make([]string, 0, len(remote))
+	for _, rv := range remote {
+		versions = append(versions, rv.Version)
+	}
+
+	ver := latestMatching(versions, spec)
+	if ver == "" {
+		return "", fmt.Errorf("%s に %s に一致するバージョンがありません", toolName, spec)
+	}
+	return ver, nil
+}
+
+// versions のうち spec に一致する最新のバージョンを返す
+func latestMatching(versions []string, spec string) string {
+	best := ""
+	for _, v := range versions {
+		if !MatchesVersionSpec(spec, v) {
+			continue
+		}
+		if best == "" || compareVersions(v, best) > 0 {
+			best = v
+		}
+	}
+	return best
+}
+
+// ドット区切りのバージョンを比較する（数値の部分は数値として比較）
+// a < b なら負、a == b なら 0、a > b なら正を返す
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		if i >= len(as) {
+			return -1
+		}
+		if i >= len(bs) {
+			return 1
+		}
+
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return an - bn
+			}
+		default:
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return 0
+}
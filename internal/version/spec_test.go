@@ -0,0 +1,75 @@
+package version
+
+import (
+	"os"
+	"testing"
+)
+
+// バージョン指定の前方一致をテストする
+func TestMatchesVersionSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		version string
+		want    bool
+	}{
+		{"1.21", "1.21", true},
+		{"1.21", "1.21.5", true},
+		{"1.21", "1.210.0", false},
+		{"18", "18.19.0", true},
+		{"18.19.0", "18.19.0", true},
+		{"18.19.0", "18.19.1", false},
+	}
+
+	for _, tt := range tests {
+		if got := MatchesVersionSpec(tt.spec, tt.version); got != tt.want {
+			t.Errorf("MatchesVersionSpec(%q, %q) = %v, want %v", tt.spec, tt.version, got, tt.want)
+		}
+	}
+}
+
+// 数値として比較されるかテストする
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int // 符号のみ比較
+	}{
+		{"1.9.0", "1.10.0", -1},
+		{"20.10.0", "20.10.0", 0},
+		{"1.21", "1.21.1", -1},
+		{"3.12.0", "3.12.0rc1", -1},
+	}
+
+	for _, tt := range tests {
+		got := compareVersions(tt.a, tt.b)
+		if (got < 0 && tt.want >= 0) || (got > 0 && tt.want <= 0) || (got == 0 && tt.want != 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, want sign %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// インストール済みバージョンから最新の一致が選ばれるかテストする
+func TestManagerFindInstalled(t *testing.T) {
+	m, paths := newTestManager(t, nil)
+
+	for _, v := range []string{"1.9.0", "1.21.0", "1.21.10", "1.21.9"} {
+		if err := os.MkdirAll(paths.ToolVersionPath("node", v), 0755); err != nil {
+			t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+		}
+	}
+
+	got, err := m.FindInstalled("node", "1.21")
+	if err != nil {
+		t.Fatalf("FindInstalled() エラー: %v", err)
+	}
+	if got != "1.21.10" {
+		t.Errorf("FindInstalled(1.21) = %q, want %q", got, "1.21.10")
+	}
+
+	got, err = m.FindInstalled("node", "2")
+	if err != nil {
+		t.Fatalf("FindInstalled() エラー: %v", err)
+	}
+	if got != "" {
+		t.Errorf("FindInstalled(2) = %q, want empty", got)
+	}
+}
@@ -0,0 +1,369 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arsenal/internal/terminal"
+)
+
+// Sync の動作を指定する
+type SyncOptions struct {
+	// ロックファイルに記録されたバージョンとチェックサムだけでインストールし、
+	// .toolversions やプラグイン定義との食い違いがあれば何もせずに失敗する
+	Frozen bool
+	// ベースの指定に重ねるプロファイル（nil なら ARSENAL_PROFILE を使う）
+	Profiles []string
+	// インストールと切り替えの計画を表示するだけで何も変更しない
+	DryRun bool
+	// 環境が .toolversions と一致しなければエラーを返す（何も変更しない）
+	Check bool
+	// dir の .toolversions の代わりに ~/.arsenal/toolversions を同期する（ロックファイルは使わない）
+	Global bool
+}
+
+// ツールの同期結果
+type syncStatus int
+
+const (
+	syncOK syncStatus = iota
+	syncSwitched
+	syncInstalled
+	syncFailed
+)
+
+func (s syncStatus) String() string {
+	switch s {
+	case syncSwitched:
+		return "切り替え"
+	case syncInstalled:
+		return "インストール"
+	case syncFailed:
+		return "失敗"
+	default:
+		return "OK"
+	}
+}
+
+// 1 つのツールの同期の計画と結果
+type syncItem struct {
+	tool    string
+	specs   []syncSpec
+	current string // 同期前のアクティブなバージョン（未設定なら空）
+	status  syncStatus
+	errs    []string
+}
+
+// 1 つのバージョン指定の同期の計画
+type syncSpec struct {
+	entry   LockedTool
+	url     string
+	sha256  string // ロックファイルに記録されたチェックサム（なければ空）
+	install bool
+}
+
+// 計画通りに同期できた場合に切り替わるバージョン（指定の先頭）
+func (it *syncItem) target() string {
+	if len(it.specs) == 0 {
+		return ""
+	}
+	return it.specs[0].entry.Version
+}
+
+// 表示用のバージョン一覧
+func (it *syncItem) versions() string {
+	var versions []string
+	for _, s := range it.specs {
+		versions = append(versions, s.entry.Version)
+	}
+	return strings.Join(versions, " ")
+}
+
+func (it *syncItem) fail(format string, args ...interface{}) {
+	it.status = syncFailed
+	it.errs = append(it.errs, fmt.Sprintf(format, args...))
+}
+
+// .toolversions で指定された全バージョンをインストールして切り替える
+// バージョン指定はロックファイルで解決し、結果をロックファイルに書き戻す（Frozen では書き込まない）
+// インストールや切り替えに失敗したツールがあれば、それらをまとめたエラーを返す
+func (m *Manager) Sync(dir string, opts SyncOptions) error {
+	if opts.Global && opts.Frozen {
+		return fmt.Errorf("--global では --frozen を使えません (グローバルのデフォルトにはロックファイルがありません)")
+	}
+
+	var tv *ToolVersions
+	var err error
+	if opts.Global {
+		tv, err = m.ReadGlobalToolVersions(opts.Profiles)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%s がありません (bastion-arsenal use --global <tool> <version> で作成)", m.GlobalToolVersionsPath())
+		}
+	} else {
+		tv, err = m.ReadToolVersions(dir, opts.Profiles)
+	}
+	if err != nil {
+		return fmt.Errorf(".toolversions 読み込みエラー: %w", err)
+	}
+	if err := tv.CheckProfiles(opts.Profiles); err != nil {
+		return err
+	}
+
+	lock := &LockFile{Version: lockFileVersion}
+	lockPath := ""
+	var specs map[string][]string
+	if !opts.Global {
+		// 他のプロファイルのエントリもロックファイルに残すため、全プロファイルの指定を集める
+		if _, specs, err = m.lockableSpecs(dir); err != nil {
+			return fmt.Errorf(".toolversions 読み込みエラー: %w", err)
+		}
+
+		lockPath = tv.LockPath()
+		if opts.Frozen {
+			if _, err := os.Stat(lockPath); err != nil {
+				return fmt.Errorf("--frozen にはロックファイルが必要です (bastion-arsenal lock で作成): %w", err)
+			}
+		}
+		if lock, err = LoadLockFile(lockPath); err != nil {
+			return err
+		}
+		if opts.Frozen {
+			if drift := m.lockDrift(tv, specs, lock); len(drift) > 0 {
+				return driftError(lockPath, drift)
+			}
+		}
+	}
+
+	if profiles := activeProfiles(tv, opts.Profiles); len(profiles) > 0 {
+		terminal.PrintInfo("%s から同期中 (プロファイル: %s)", strings.Join(tv.Files(), ", "), strings.Join(profiles, ", "))
+	} else {
+		terminal.PrintInfo("%s から同期中", strings.Join(tv.Files(), ", "))
+	}
+
+	items := m.planSync(tv, lock)
+
+	if opts.DryRun || opts.Check {
+		changes := printSyncPlan(items)
+		if opts.Check && changes > 0 {
+			return fmt.Errorf("環境が .toolversions と一致しません (%d 件)", changes)
+		}
+		return nil
+	}
+
+	synced := make(map[string]LockedTool)
+	for _, item := range items {
+		m.syncTool(item, synced, lockPath != "" && !opts.Frozen)
+	}
+
+	// 切り替え結果を ~/.arsenal/bin に反映
+	if err := m.RebuildBin(); err != nil {
+		return fmt.Errorf("bin ディレクトリ更新エラー: %w", err)
+	}
+
+	if lockPath != "" && !opts.Frozen {
+		// 同期しなかった指定（他のプロファイルやインストールに失敗したもの）は元のエントリを残す
+		newLock := &LockFile{Version: lockFileVersion}
+		for _, tool := range sortedTools(specs) {
+			for _, spec := range specs[tool] {
+				if entry, ok := synced[tool+" "+spec]; ok {
+					newLock.Tools = append(newLock.Tools, entry)
+				} else if locked := lock.Find(tool, spec); locked != nil {
+					newLock.Tools = append(newLock.Tools, *locked)
+				}
+			}
+		}
+		if err := newLock.Save(lockPath); err != nil {
+			return fmt.Errorf("%s 書き込みエラー: %w", lockPath, err)
+		}
+	}
+
+	fmt.Println()
+	printSyncSummary(items)
+
+	var failed []string
+	for _, item := range items {
+		if item.status == syncFailed {
+			failed = append(failed, fmt.Sprintf("%s: %s", item.tool, strings.Join(item.errs, "; ")))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 個のツールの同期に失敗しました:\n  %s", len(failed), strings.Join(failed, "\n  "))
+	}
+
+	fmt.Println()
+	terminal.PrintSuccess("同期完了")
+	return nil
+}
+
+// ツールごとに、ロックファイルで解決したバージョンとインストールの要否を調べる
+func (m *Manager) planSync(tv *ToolVersions, lock *LockFile) []*syncItem {
+	platform := currentPlatform()
+
+	var items []*syncItem
+	for _, tool := range sortedTools(tv.Tools) {
+		if m.isUnknownAsdfTool(tv, tool) {
+			terminal.PrintWarning("%s の %s に対応するプラグインがないためスキップします", tv.Origins[tool], tool)
+			continue
+		}
+
+		item := &syncItem{tool: tool}
+		items = append(items, item)
+
+		p, err := m.registry.Get(tool)
+		if err != nil {
+			item.fail("%v", err)
+			continue
+		}
+		item.current, _ = m.Current(tool)
+
+		for _, spec := range tv.Tools[tool] {
+			// ロックされたバージョンがあればそれを使う
+			locked := lock.Find(tool, spec)
+			entry := LockedTool{Name: tool, Spec: spec}
+			if locked != nil && MatchesVersionSpec(spec, locked.Version) {
+				entry.Version = locked.Version
+				entry.Platforms = append([]LockedPlatform(nil), locked.Platforms...)
+			} else {
+				entry.Version = m.resolveSpec(tool, spec)
+			}
+
+			// ロックファイルのチェックサムと同じ URL からのダウンロードなら検証する
+			s := syncSpec{entry: entry, url: p.ResolveDownloadURLFor(entry.Version, platform)}
+			if pe := entry.Platform(platform); pe != nil && pe.URL == s.url {
+				s.sha256 = pe.SHA256
+			}
+			s.install = !m.isInstalled(tool, entry.Version)
+			item.specs = append(item.specs, s)
+		}
+	}
+	return items
+}
+
+// 計画を表示し、必要な変更（インストール、切り替え、失敗）の件数を返す
+func printSyncPlan(items []*syncItem) int {
+	changes := 0
+	fmt.Println()
+	for _, item := range items {
+		for _, e := range item.errs {
+			terminal.PrintfRed("  失敗: %s: %s\n", item.tool, e)
+			changes++
+		}
+		for _, s := range item.specs {
+			if s.install {
+				terminal.PrintfGreen("  インストール: %s %s\n", item.tool, s.entry.Version)
+				changes++
+			}
+		}
+		if target := item.target(); target != "" && target != item.current {
+			current := item.current
+			if current == "" {
+				current = "(未設定)"
+			}
+			terminal.PrintfYellow("  切り替え: %s %s -> %s\n", item.tool, current, target)
+			changes++
+		}
+	}
+
+	if changes == 0 {
+		terminal.PrintSuccess("環境は .toolversions と一致しています")
+	}
+	return changes
+}
+
+// 計画に従って 1 つのツールをインストールして切り替える
+// 複数のバージョンが指定されている場合は全てインストールし、
+// インストールできた最初のバージョンに切り替える
+// writeLock ではインストール済みでチェックサムが記録されていないバージョンのアーカイブも取得して記録する
+func (m *Manager) syncTool(item *syncItem, synced map[string]LockedTool, writeLock bool) {
+	if item.status == syncFailed {
+		return
+	}
+
+	installed := false
+	active := ""
+	for _, s := range item.specs {
+		entry := s.entry
+		version := entry.Version
+
+		fmt.Println()
+		terminal.PrintfCyan("── %s %s ──\n", item.tool, version)
+
+		sum := s.sha256
+		if s.install {
+			var err error
+			sum, err = m.install(item.tool, version, installOptions{sha256: sum})
+			if err != nil {
+				terminal.PrintWarning("%s %s のインストールに失敗: %v", item.tool, version, err)
+				item.fail("%s のインストールに失敗: %v", version, err)
+				continue
+			}
+			installed = true
+		} else {
+			terminal.PrintlnYellow("   既にインストール済み")
+			if writeLock && sum == "" {
+				// 他の環境の sync --frozen で検証できるよう、チェックサムのないエントリは書かない
+				var err error
+				if sum, err = m.hashURL(s.url); err != nil {
+					terminal.PrintWarning("%s %s のチェックサムを取得できません (ロックファイルは更新しません): %v", item.tool, version, err)
+				}
+			}
+		}
+
+		if sum != "" || !writeLock {
+			entry.setPlatform(LockedPlatform{Platform: currentPlatform().String(), URL: s.url, SHA256: sum})
+			synced[item.tool+" "+entry.Spec] = entry
+		}
+
+		if active == "" {
+			active = version
+		}
+	}
+
+	switched := false
+	if active != "" && active != item.current {
+		if err := m.switchVersion(item.tool, active); err != nil {
+			terminal.PrintWarning("%s を %s に切り替えるのに失敗: %v", item.tool, active, err)
+			item.fail("%s への切り替えに失敗: %v", active, err)
+		} else {
+			switched = true
+		}
+	}
+
+	switch {
+	case item.status == syncFailed:
+	case installed:
+		item.status = syncInstalled
+	case switched:
+		item.status = syncSwitched
+	default:
+		item.status = syncOK
+	}
+}
+
+// ツールごとの同期結果と件数を表示する
+func printSyncSummary(items []*syncItem) {
+	toolWidth, versionWidth := 0, 0
+	for _, item := range items {
+		toolWidth = max(toolWidth, len(item.tool))
+		versionWidth = max(versionWidth, len(item.versions()))
+	}
+
+	counts := make(map[syncStatus]int)
+	for _, item := range items {
+		counts[item.status]++
+
+		status := item.status.String()
+		switch item.status {
+		case syncFailed:
+			status = terminal.Red(status)
+		case syncInstalled, syncSwitched:
+			status = terminal.Green(status)
+		}
+		fmt.Printf("  %-*s  %-*s  %s\n", toolWidth, item.tool, versionWidth, item.versions(), status)
+	}
+
+	fmt.Println()
+	fmt.Printf("インストール %d / 切り替え %d / OK %d / 失敗 %d\n",
+		counts[syncInstalled], counts[syncSwitched], counts[syncOK], counts[syncFailed])
+}
@@ -0,0 +1,300 @@
+package version
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/arsenal/internal/config"
+	"github.com/arsenal/internal/terminal"
+)
+
+// 再帰的な同期で並列にインストールする数
+const recursiveInstallWorkers = 4
+
+// 再帰的な同期でインストールする 1 つのバージョン
+type recursiveInstall struct {
+	tool      string
+	version   string
+	sha256    string   // ロックファイルに記録されたチェックサム（なければ空）
+	projects  []string // このバージョンを必要とするサブプロジェクト
+	installed bool     // 同期前からインストール済み
+	err       error
+}
+
+// 1 つのサブプロジェクトが必要とするバージョン
+type subproject struct {
+	dir   string // root からの相対パス
+	needs []*recursiveInstall
+	errs  []string
+}
+
+// root 以下の全てのバージョンファイル（.gitignore で無視されるものを除く）が必要とするバージョンを
+// 重複なく並列にインストールする。グローバルの symlink は変更せず、ロックファイルも書き込まない
+// バージョンファイルは sync と同じく .toolversions、.tool-versions、プラグインの legacy_files を探す
+// 各サブプロジェクトでは上位ディレクトリの指定も統合し、ロックされたバージョンがあればそれを使う
+func (m *Manager) SyncRecursive(root string, opts SyncOptions) error {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return err
+	}
+
+	dirs, err := m.findVersionFileDirs(absRoot, opts.Profiles)
+	if err != nil {
+		return fmt.Errorf("%s の検索エラー: %w", root, err)
+	}
+	if len(dirs) == 0 {
+		return fmt.Errorf("%w (%s 以下)", ErrToolVersionsNotFound, root)
+	}
+
+	terminal.PrintInfo("%s 以下の %d 個のディレクトリのバージョンファイルから同期中", absRoot, len(dirs))
+
+	projects, installs, err := m.collectSubprojects(absRoot, dirs, opts.Profiles)
+	if err != nil {
+		return err
+	}
+
+	printSubprojects(projects)
+
+	var missing []*recursiveInstall
+	for _, in := range installs {
+		if !in.installed {
+			missing = append(missing, in)
+		}
+	}
+
+	if opts.DryRun || opts.Check {
+		fmt.Println()
+		changes := 0
+		for _, sp := range projects {
+			changes += len(sp.errs)
+		}
+		if len(missing) == 0 && changes == 0 {
+			terminal.PrintSuccess("必要なバージョンは全てインストール済みです")
+			return nil
+		}
+		for _, in := range missing {
+			terminal.PrintfGreen("  インストール: %s %s", in.tool, in.version)
+			fmt.Printf(" (%s)\n", strings.Join(in.projects, ", "))
+		}
+		changes += len(missing)
+		if opts.Check && changes > 0 {
+			return fmt.Errorf("必要なバージョンがインストールされていません (%d 件)", changes)
+		}
+		return nil
+	}
+
+	if len(missing) > 0 {
+		fmt.Println()
+		terminal.PrintInfo("%d 個のバージョンをインストール中...", len(missing))
+		m.installParallel(missing)
+
+		// 並列インストールではシムを更新しないため、ここでまとめて更新する
+		if err := m.refreshShims(); err != nil {
+			return fmt.Errorf("シム更新エラー: %w", err)
+		}
+	}
+
+	var failed []string
+	for _, sp := range projects {
+		for _, e := range sp.errs {
+			failed = append(failed, fmt.Sprintf("%s: %s", sp.dir, e))
+		}
+	}
+	installed := 0
+	for _, in := range missing {
+		if in.err != nil {
+			failed = append(failed, fmt.Sprintf("%s %s: %v", in.tool, in.version, in.err))
+		} else {
+			installed++
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("インストール %d / インストール済み %d / 失敗 %d\n", installed, len(installs)-len(missing), len(failed))
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 件の同期に失敗しました:\n  %s", len(failed), strings.Join(failed, "\n  "))
+	}
+
+	fmt.Println()
+	terminal.PrintSuccess("同期完了 (グローバルのバージョンは変更していません)")
+	return nil
+}
+
+// root 以下でバージョンファイルからバージョンを読めるディレクトリをパス順で返す
+// バージョンを読めない legacy ファイル（toolchain 行のない go.mod など）しかないディレクトリは除く
+func (m *Manager) findVersionFileDirs(root string, profiles []string) ([]string, error) {
+	names := []string{config.ToolVersionFile, config.AsdfToolVersionFile}
+	for _, p := range m.registry.All() {
+		for _, name := range p.LegacyFiles {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	candidates, err := findFilesRespectingGitignore(root, names)
+	if err != nil {
+		return nil, err
+	}
+
+	if profiles == nil {
+		profiles = ProfilesFromEnv()
+	}
+	var dirs []string
+	for _, dir := range candidates {
+		if _, ok, err := m.readVersionFilesIn(dir, profiles); err != nil || ok {
+			// 読み込みエラーはサブプロジェクトのエラーとして表示する
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// 各サブプロジェクトのバージョン指定を解決し、必要なバージョンの和をツール名とバージョンの順で返す
+func (m *Manager) collectSubprojects(absRoot string, dirs []string, profiles []string) ([]*subproject, []*recursiveInstall, error) {
+	platform := currentPlatform()
+	byKey := make(map[string]*recursiveInstall)
+	resolved := make(map[string]string) // ロックされていない指定の解決結果（リモートの一覧の取得を 1 回にする）
+	defined := &ToolVersions{}
+
+	var projects []*subproject
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(absRoot, dir)
+		if err != nil {
+			rel = dir
+		}
+		sp := &subproject{dir: rel}
+		projects = append(projects, sp)
+
+		tv, err := m.ReadToolVersions(dir, profiles)
+		if err != nil {
+			sp.errs = append(sp.errs, err.Error())
+			continue
+		}
+		for _, p := range tv.Profiles {
+			if !slices.Contains(defined.Profiles, p) {
+				defined.Profiles = append(defined.Profiles, p)
+			}
+		}
+
+		lock, err := LoadLockFile(tv.LockPath())
+		if err != nil {
+			sp.errs = append(sp.errs, err.Error())
+			lock = &LockFile{}
+		}
+
+		for _, tool := range sortedTools(tv.Tools) {
+			if m.isUnknownAsdfTool(tv, tool) {
+				continue
+			}
+			p, err := m.registry.Get(tool)
+			if err != nil {
+				sp.errs = append(sp.errs, err.Error())
+				continue
+			}
+
+			for _, spec := range tv.Tools[tool] {
+				version, sum := "", ""
+				if locked := lock.Find(tool, spec); locked != nil && MatchesVersionSpec(spec, locked.Version) {
+					version = locked.Version
+					if pe := locked.Platform(platform); pe != nil && pe.URL == p.ResolveDownloadURLFor(version, platform) {
+						sum = pe.SHA256
+					}
+				} else {
+					key := tool + " " + spec
+					if _, ok := resolved[key]; !ok {
+						resolved[key] = m.resolveSpec(tool, spec)
+					}
+					version = resolved[key]
+				}
+
+				key := tool + " " + version
+				in, ok := byKey[key]
+				if !ok {
+					in = &recursiveInstall{tool: tool, version: version, installed: m.isInstalled(tool, version)}
+					byKey[key] = in
+				}
+				if in.sha256 == "" {
+					in.sha256 = sum
+				}
+				if !slices.Contains(in.projects, rel) {
+					in.projects = append(in.projects, rel)
+				}
+				sp.needs = append(sp.needs, in)
+			}
+		}
+	}
+
+	if err := defined.CheckProfiles(profiles); err != nil {
+		return nil, nil, err
+	}
+
+	installs := make([]*recursiveInstall, 0, len(byKey))
+	for _, in := range byKey {
+		installs = append(installs, in)
+	}
+	sort.Slice(installs, func(i, j int) bool {
+		if installs[i].tool != installs[j].tool {
+			return installs[i].tool < installs[j].tool
+		}
+		return compareVersions(installs[i].version, installs[j].version) < 0
+	})
+	return projects, installs, nil
+}
+
+// サブプロジェクトごとに必要なバージョンとインストール状況を表示する
+func printSubprojects(projects []*subproject) {
+	fmt.Println()
+	terminal.PrintlnBlue("サブプロジェクト:")
+	for _, sp := range projects {
+		fmt.Println()
+		fmt.Printf("  %s\n", sp.dir)
+		for _, in := range sp.needs {
+			if in.installed {
+				fmt.Printf("    %s %s\n", in.tool, terminal.Green(in.version))
+			} else {
+				fmt.Printf("    %s %s\n", in.tool, terminal.Yellow(in.version+" (未インストール)"))
+			}
+		}
+		for _, e := range sp.errs {
+			fmt.Printf("    %s\n", terminal.Red(e))
+		}
+	}
+}
+
+// 各バージョンを並列にインストールする（結果は recursiveInstall.err に入る）
+func (m *Manager) installParallel(installs []*recursiveInstall) {
+	jobs := make(chan *recursiveInstall)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	for i := 0; i < min(recursiveInstallWorkers, len(installs)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for in := range jobs {
+				_, err := m.install(in.tool, in.version, installOptions{sha256: in.sha256, quiet: true})
+
+				mu.Lock()
+				in.err = err
+				if err != nil {
+					terminal.PrintWarning("%s %s のインストールに失敗: %v", in.tool, in.version, err)
+				} else {
+					terminal.PrintSuccess("%s %s をインストールしました (%s)", in.tool, in.version, strings.Join(in.projects, ", "))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, in := range installs {
+		jobs <- in
+	}
+	close(jobs)
+	wg.Wait()
+}
@@ -0,0 +1,237 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+)
+
+// --check と --dry-run が何も変更せず、--check が食い違いをエラーにするかテストする
+func TestManagerSyncCheckAndDryRun(t *testing.T) {
+	m, paths, downloads := setupLockTest(t)
+
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("testnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+	lockPath := filepath.Join(projectDir, config.ToolVersionLockFile)
+
+	if err := m.Sync(projectDir, SyncOptions{DryRun: true}); err != nil {
+		t.Fatalf("Sync(dry-run) エラー: %v", err)
+	}
+	err := m.Sync(projectDir, SyncOptions{Check: true})
+	if err == nil || !strings.Contains(err.Error(), "一致しません") {
+		t.Errorf("Sync(check) = %v, want 一致しないエラー", err)
+	}
+	if m.isInstalled("testnode", "20.10.0") || atomic.LoadInt32(downloads) != 0 {
+		t.Error("--dry-run / --check でインストールされました")
+	}
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Error("--dry-run / --check でロックファイルが書き込まれました")
+	}
+
+	if err := m.Sync(projectDir, SyncOptions{}); err != nil {
+		t.Fatalf("Sync() エラー: %v", err)
+	}
+	if err := m.Sync(projectDir, SyncOptions{Check: true}); err != nil {
+		t.Errorf("同期後の Sync(check) エラー: %v", err)
+	}
+
+	// 別のバージョンに切り替わっていれば一致しない
+	if err := os.MkdirAll(paths.ToolVersionPath("testnode", "18.19.0"), 0755); err != nil {
+		t.Fatalf("バージョンディレクトリ作成エラー: %v", err)
+	}
+	if err := m.switchVersion("testnode", "18.19.0"); err != nil {
+		t.Fatalf("switchVersion() エラー: %v", err)
+	}
+	if err := m.Sync(projectDir, SyncOptions{Check: true}); err == nil {
+		t.Error("切り替えが必要なのに Sync(check) が成功しました")
+	}
+}
+
+// 同期に失敗したツールがあればまとめてエラーを返し、他のツールは同期するかテストする
+func TestManagerSyncFailure(t *testing.T) {
+	m, _, _ := setupLockTest(t)
+
+	projectDir := t.TempDir()
+	content := "nosuchtool 1.0.0\ntestnode 20.10.0\n"
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte(content), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	err := m.Sync(projectDir, SyncOptions{})
+	if err == nil || !strings.Contains(err.Error(), "nosuchtool") {
+		t.Fatalf("Sync() = %v, want nosuchtool の失敗", err)
+	}
+	if strings.Contains(err.Error(), "testnode") {
+		t.Errorf("成功したツールがエラーに含まれています: %v", err)
+	}
+
+	if !m.isInstalled("testnode", "20.10.0") {
+		t.Error("testnode がインストールされませんでした")
+	}
+	if current, _ := m.Current("testnode"); current != "20.10.0" {
+		t.Errorf("Current(testnode) = %q, want 20.10.0", current)
+	}
+}
+
+// 上位ディレクトリの .tool-versions にある未対応のツールは失敗にせず飛ばすかテストする
+func TestManagerSyncSkipsUnknownAsdfTools(t *testing.T) {
+	m, _, _ := setupLockTest(t)
+
+	// ~/.tool-versions のような上位ディレクトリの asdf のファイル
+	home := t.TempDir()
+	if err := os.WriteFile(filepath.Join(home, config.AsdfToolVersionFile), []byte("ruby 3.3.0\n"), 0644); err != nil {
+		t.Fatalf(".tool-versions 作成エラー: %v", err)
+	}
+	projectDir := filepath.Join(home, "project")
+	if err := os.MkdirAll(projectDir, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("testnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	if err := m.Sync(projectDir, SyncOptions{}); err != nil {
+		t.Fatalf("Sync() エラー: %v", err)
+	}
+	if !m.isInstalled("testnode", "20.10.0") {
+		t.Error("testnode がインストールされませんでした")
+	}
+
+	lock, err := LoadLockFile(filepath.Join(projectDir, config.ToolVersionLockFile))
+	if err != nil {
+		t.Fatalf("LoadLockFile() エラー: %v", err)
+	}
+	for _, entry := range lock.Tools {
+		if entry.Name == "ruby" {
+			t.Errorf("未対応のツールがロックされています: %+v", entry)
+		}
+	}
+
+	if err := m.Sync(projectDir, SyncOptions{Frozen: true}); err != nil {
+		t.Errorf("Sync(frozen) エラー: %v", err)
+	}
+
+	// .toolversions に書かれた未対応のツールは従来どおり失敗する
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("ruby 3.3.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 更新エラー: %v", err)
+	}
+	if err := m.Sync(projectDir, SyncOptions{}); err == nil {
+		t.Error(".toolversions の未対応のツールでエラーが返されませんでした")
+	}
+}
+
+// Global で ~/.arsenal/toolversions をインストールして切り替え、ロックファイルを書かないかテストする
+func TestManagerSyncGlobal(t *testing.T) {
+	m, paths, _ := setupLockTest(t)
+
+	if err := m.Sync(t.TempDir(), SyncOptions{Global: true}); err == nil {
+		t.Error("グローバルのデフォルトがないのにエラーが返されませんでした")
+	}
+	if err := m.Sync(t.TempDir(), SyncOptions{Global: true, Frozen: true}); err == nil {
+		t.Error("--global と --frozen でエラーが返されませんでした")
+	}
+
+	if err := os.WriteFile(paths.GlobalToolVersionsPath(), []byte("testnode 20.10.0\n"), 0644); err != nil {
+		t.Fatalf("グローバルのデフォルト作成エラー: %v", err)
+	}
+
+	// プロジェクトの .toolversions は使わない
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("testnode 18.19.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	if err := m.Sync(projectDir, SyncOptions{Global: true}); err != nil {
+		t.Fatalf("Sync(global) エラー: %v", err)
+	}
+	if current, _ := m.Current("testnode"); current != "20.10.0" {
+		t.Errorf("Current(testnode) = %q, want 20.10.0", current)
+	}
+	if m.isInstalled("testnode", "18.19.0") {
+		t.Error("プロジェクトの指定がインストールされました")
+	}
+	for _, dir := range []string{projectDir, paths.Root} {
+		if _, err := os.Stat(filepath.Join(dir, config.ToolVersionLockFile)); !os.IsNotExist(err) {
+			t.Errorf("%s にロックファイルが書き込まれました", dir)
+		}
+	}
+}
+
+// サブプロジェクトが必要とするバージョンを 1 回ずつインストールし、グローバルは変更しないかテストする
+func TestManagerSyncRecursive(t *testing.T) {
+	m, _, downloads := setupLockTest(t)
+
+	root := t.TempDir()
+	files := map[string]string{
+		".git/HEAD":                      "ref: refs/heads/main\n",
+		".gitignore":                     "ignored/\n",
+		"services/api/.toolversions":     "testnode 20.10.0\n",
+		"services/web/.toolversions":     "testnode 20.10.0 18.19.0\n",
+		"services/batch/.toolversions":   "testnode 18.19.0\n",
+		"services/asdf/.tool-versions":   "testnode 22.1.0\n",
+		"ignored/.toolversions":          "testnode 16.20.2\n",
+		"services/ignored/.toolversions": "testnode 16.20.2\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("ディレクトリ作成エラー: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("テストファイル作成エラー: %v", err)
+		}
+	}
+
+	if err := m.SyncRecursive(root, SyncOptions{DryRun: true}); err != nil {
+		t.Fatalf("SyncRecursive(dry-run) エラー: %v", err)
+	}
+	if err := m.SyncRecursive(root, SyncOptions{Check: true}); err == nil {
+		t.Error("未インストールのバージョンがあるのに SyncRecursive(check) が成功しました")
+	}
+	if atomic.LoadInt32(downloads) != 0 {
+		t.Fatalf("--dry-run / --check で %d 回ダウンロードしました", atomic.LoadInt32(downloads))
+	}
+
+	if err := m.SyncRecursive(root, SyncOptions{}); err != nil {
+		t.Fatalf("SyncRecursive() エラー: %v", err)
+	}
+	if atomic.LoadInt32(downloads) != 3 {
+		t.Errorf("ダウンロード回数 = %d, want 3", atomic.LoadInt32(downloads))
+	}
+	for _, v := range []string{"20.10.0", "18.19.0", "22.1.0"} {
+		if !m.isInstalled("testnode", v) {
+			t.Errorf("testnode %s がインストールされませんでした", v)
+		}
+	}
+	if m.isInstalled("testnode", "16.20.2") {
+		t.Error(".gitignore で無視したディレクトリの指定がインストールされました")
+	}
+	if current, _ := m.Current("testnode"); current != "" {
+		t.Errorf("グローバルのバージョンが %q に変更されました", current)
+	}
+	if _, err := os.Stat(filepath.Join(root, "services", "api", config.ToolVersionLockFile)); !os.IsNotExist(err) {
+		t.Error("ロックファイルが書き込まれました")
+	}
+	if err := m.SyncRecursive(root, SyncOptions{Check: true}); err != nil {
+		t.Errorf("同期後の SyncRecursive(check) エラー: %v", err)
+	}
+	// サブディレクトリから同期してもリポジトリのルートの .gitignore に従う
+	if err := m.SyncRecursive(filepath.Join(root, "services"), SyncOptions{Check: true}); err != nil {
+		t.Errorf("SyncRecursive(services, check) エラー: %v", err)
+	}
+
+	// 失敗したサブプロジェクトはまとめて報告する
+	if err := os.WriteFile(filepath.Join(root, "services", "batch", config.ToolVersionFile), []byte("nosuchtool 1.0.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 更新エラー: %v", err)
+	}
+	err := m.SyncRecursive(root, SyncOptions{})
+	if err == nil || !strings.Contains(err.Error(), filepath.Join("services", "batch")) {
+		t.Errorf("SyncRecursive() = %v, want services/batch の失敗", err)
+	}
+}
@@ -1,149 +1,337 @@
 package version
 
 import (
-	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/arsenal/internal/config"
-	"github.com/arsenal/internal/terminal"
 )
 
+// 上位ディレクトリを辿っても .toolversions が見つからないことを表す
+var ErrToolVersionsNotFound = errors.New(config.ToolVersionFile + " が見つかりません")
+
+// プロファイルを選ぶ環境変数（カンマ区切りで複数指定できる）
+const ProfileEnvVar = "ARSENAL_PROFILE"
+
 // .toolversions ファイルの内容を表す
 type ToolVersions struct {
-	Tools map[string]string // tool -> version
+	Tools   map[string][]string // tool -> 優先順のバージョン（先頭から探してインストール済みのものを使う）
+	Origins map[string]string   // tool -> 指定元のファイル
+	// tool -> 指定元のプロファイル（ベースの指定なら含まれない）
+	OriginProfiles map[string]string
+	// より近いファイルやプロファイルに上書きされて使われないエントリ（近い順）
+	Shadowed []ToolVersionsEntry
+	// ファイルに定義されている全てのプロファイル
+	Profiles []string
+
+	// バージョンファイルが見つかった最も近いディレクトリ
+	nearestDir string
 }
 
-// 指定ディレクトリから .toolversions ファイルを読み込むか、
-// 上位ディレクトリを辿って探す
-func ReadToolVersions(dir string) (*ToolVersions, string, error) {
-	path, err := findToolVersionsFile(dir)
-	if err != nil {
-		return nil, "", err
+// バージョンファイルの 1 エントリを表す
+type ToolVersionsEntry struct {
+	Tool     string
+	Versions []string
+	Origin   string
+	Profile  string // ベースの指定なら空
+}
+
+// ARSENAL_PROFILE で選ばれたプロファイルを返す
+func ProfilesFromEnv() []string {
+	var profiles []string
+	for _, p := range strings.Split(os.Getenv(ProfileEnvVar), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			profiles = append(profiles, p)
+		}
+	}
+	return profiles
+}
+
+// 指定ディレクトリからルートまで上位ディレクトリを辿り、見つかった全てのバージョンファイルを統合する
+// ツールごとに最も近いディレクトリの指定を使う
+// 同じディレクトリ内では .toolversions > .tool-versions > legacy_files（プラグインでの記載順）の順に優先する
+// 各ファイルではベースの指定に profiles のセクションを順に重ねる（nil なら ARSENAL_PROFILE を使う）
+func (m *Manager) ReadToolVersions(dir string, profiles []string) (*ToolVersions, error) {
+	if profiles == nil {
+		profiles = ProfilesFromEnv()
 	}
 
-	tv, err := parseToolVersionsFile(path)
+	absDir, err := filepath.Abs(dir)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	merged := newToolVersions()
+	found := false
+
+	for {
+		tv, ok, err := m.readVersionFilesIn(absDir, profiles)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			found = true
+			merged.merge(tv)
+		}
+
+		parent := filepath.Dir(absDir)
+		if parent == absDir {
+			break // ルートに到達
+		}
+		absDir = parent
+	}
+
+	if !found {
+		return nil, fmt.Errorf("%w (%s から / まで検索)", ErrToolVersionsNotFound, dir)
 	}
+	return merged, nil
+}
 
-	return tv, path, nil
+// グローバルのデフォルトのバージョンファイルのパスを返す
+func (m *Manager) GlobalToolVersionsPath() string {
+	return m.paths.GlobalToolVersionsPath()
 }
 
-// .toolversions ファイルを書き込む
-func WriteToolVersions(dir string, tv *ToolVersions) error {
-	path := filepath.Join(dir, config.ToolVersionFile)
-	f, err := os.Create(path)
+// グローバルのデフォルトのバージョンファイル（~/.arsenal/toolversions）を読み込む
+// 書式は .toolversions と同じ（profiles が nil なら ARSENAL_PROFILE を使う）
+// ファイルがなければ os.IsNotExist で判定できるエラーを返す
+func (m *Manager) ReadGlobalToolVersions(profiles []string) (*ToolVersions, error) {
+	if profiles == nil {
+		profiles = ProfilesFromEnv()
+	}
+
+	path := m.GlobalToolVersionsPath()
+	tv, err := parseToolVersionsFile(path, profiles)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	tv.nearestDir = filepath.Dir(path)
+	return tv, nil
+}
+
+func newToolVersions() *ToolVersions {
+	return &ToolVersions{
+		Tools:          make(map[string][]string),
+		Origins:        make(map[string]string),
+		OriginProfiles: make(map[string]string),
 	}
-	defer func() { _ = f.Close() }()
+}
 
-	for tool, version := range tv.Tools {
-		if _, err := fmt.Fprintf(f, "%s %s\n", tool, version); err != nil {
-			return err
+// より遠いファイルの内容を統合する（既にあるツールは上書きされたエントリとして記録する）
+func (tv *ToolVersions) merge(farther *ToolVersions) {
+	for _, tool := range sortedTools(farther.Tools) {
+		entry := ToolVersionsEntry{
+			Tool:     tool,
+			Versions: farther.Tools[tool],
+			Origin:   farther.Origins[tool],
+			Profile:  farther.OriginProfiles[tool],
+		}
+		if _, ok := tv.Tools[tool]; ok {
+			tv.Shadowed = append(tv.Shadowed, entry)
+			continue
+		}
+		tv.Tools[tool] = entry.Versions
+		tv.Origins[tool] = entry.Origin
+		if entry.Profile != "" {
+			tv.OriginProfiles[tool] = entry.Profile
 		}
 	}
+	tv.Shadowed = append(tv.Shadowed, farther.Shadowed...)
 
-	return nil
+	if tv.nearestDir == "" {
+		tv.nearestDir = farther.nearestDir
+	}
+
+	for _, p := range farther.Profiles {
+		if !slices.Contains(tv.Profiles, p) {
+			tv.Profiles = append(tv.Profiles, p)
+		}
+	}
 }
 
-// .toolversions で指定された全バージョンをインストールして切り替える
-func (m *Manager) Sync(dir string) error {
-	tv, path, err := ReadToolVersions(dir)
-	if err != nil {
-		return fmt.Errorf(".toolversions 読み込みエラー: %w", err)
+// ツール名を名前順で返す
+func sortedTools(tools map[string][]string) []string {
+	names := make([]string, 0, len(tools))
+	for tool := range tools {
+		names = append(names, tool)
 	}
+	sort.Strings(names)
+	return names
+}
+
+// 1 つのディレクトリにあるバージョンファイルを読み込む
+// .toolversions か .tool-versions があるか、legacy_files からバージョンが読めた場合に found を返す
+func (m *Manager) readVersionFilesIn(dir string, profiles []string) (*ToolVersions, bool, error) {
+	tv := newToolVersions()
+	found := false
+
+	for _, name := range []string{config.ToolVersionFile, config.AsdfToolVersionFile} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		found = true
 
-	terminal.PrintInfo("%s から同期中", path)
+		fileTV, err := parseToolVersionsFile(path, profiles)
+		if err != nil {
+			return nil, false, err
+		}
+		if name == config.AsdfToolVersionFile {
+			fileTV = m.translateAsdfNames(fileTV)
+		}
+		tv.merge(fileTV)
+	}
 
-	for tool, version := range tv.Tools {
-		fmt.Println()
-		terminal.PrintfCyan("── %s %s ──\n", tool, version)
+	tools := m.registry.List()
+	sort.Strings(tools)
+	for _, tool := range tools {
+		if _, ok := tv.Tools[tool]; ok {
+			continue
+		}
+		p, err := m.registry.Get(tool)
+		if err != nil {
+			continue
+		}
 
-		// インストール済みか確認
-		versionDir := m.paths.ToolVersionPath(tool, version)
-		if _, err := os.Stat(versionDir); os.IsNotExist(err) {
-			// インストール
-			if err := m.Install(tool, version); err != nil {
-				terminal.PrintWarning("%s %s のインストールに失敗: %v", tool, version, err)
+		for _, name := range p.LegacyFiles {
+			path := filepath.Join(dir, name)
+			versions, err := readLegacyVersionFile(path)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, false, fmt.Errorf("%s 読み込みエラー: %w", path, err)
+			}
+			if len(versions) == 0 {
 				continue
 			}
-		} else {
-			terminal.PrintlnYellow("   既にインストール済み")
+
+			found = true
+			tv.Tools[tool] = versions
+			tv.Origins[tool] = path
+			break
 		}
+	}
 
-		// このバージョンに切り替え
-		if err := m.Use(tool, version); err != nil {
-			terminal.PrintWarning("%s を %s に切り替えるのに失敗: %v", tool, version, err)
+	if found {
+		tv.nearestDir = dir
+	}
+	return tv, found, nil
+}
+
+// asdf のプラグイン名（nodejs など）で書かれたツールを、プラグインの asdf_names でプラグイン名に変換する
+// 同じプラグインを指す行が複数あれば、名前順で先のもの（プラグイン名そのものなど）を使う
+func (m *Manager) translateAsdfNames(tv *ToolVersions) *ToolVersions {
+	translated := newToolVersions()
+	translated.Profiles = tv.Profiles
+	translated.nearestDir = tv.nearestDir
+
+	for _, tool := range sortedTools(tv.Tools) {
+		entry := ToolVersionsEntry{
+			Tool:     m.registry.FromAsdfName(tool),
+			Versions: tv.Tools[tool],
+			Origin:   tv.Origins[tool],
+			Profile:  tv.OriginProfiles[tool],
+		}
+		if _, ok := translated.Tools[entry.Tool]; ok {
+			translated.Shadowed = append(translated.Shadowed, entry)
 			continue
 		}
+		translated.Tools[entry.Tool] = entry.Versions
+		translated.Origins[entry.Tool] = entry.Origin
+		if entry.Profile != "" {
+			translated.OriginProfiles[entry.Tool] = entry.Profile
+		}
 	}
 
-	fmt.Println()
-	terminal.PrintSuccess("同期完了")
-	return nil
+	for _, entry := range tv.Shadowed {
+		entry.Tool = m.registry.FromAsdfName(entry.Tool)
+		translated.Shadowed = append(translated.Shadowed, entry)
+	}
+	return translated
 }
 
-// ディレクトリツリーを上に辿って .toolversions を探す
-func findToolVersionsFile(dir string) (string, error) {
-	absDir, err := filepath.Abs(dir)
-	if err != nil {
-		return "", err
+// .tool-versions で指定された、対応するプラグインのないツールか判定する
+// asdf のファイルには arsenal が扱わないツールも並ぶため、同期やロックではエラーにせず飛ばす
+func (m *Manager) isUnknownAsdfTool(tv *ToolVersions, tool string) bool {
+	if _, err := m.registry.Get(tool); err == nil {
+		return false
 	}
+	return filepath.Base(tv.Origins[tool]) == config.AsdfToolVersionFile
+}
 
-	for {
-		candidate := filepath.Join(absDir, config.ToolVersionFile)
-		if _, err := os.Stat(candidate); err == nil {
-			return candidate, nil
+// 指定元のファイルを名前順で返す
+func (tv *ToolVersions) Files() []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, path := range tv.Origins {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
 		}
+	}
+	sort.Strings(files)
+	return files
+}
 
-		parent := filepath.Dir(absDir)
-		if parent == absDir {
-			break // ルートに到達
+// 明示的に指定されたプロファイルが読み込んだファイルのどこかに定義されているか確認する
+// （ARSENAL_PROFILE はプロジェクトごとに定義がなくてもよいため確認しない）
+func (tv *ToolVersions) CheckProfiles(profiles []string) error {
+	for _, profile := range profiles {
+		if !slices.Contains(tv.Profiles, profile) {
+			if len(tv.Profiles) == 0 {
+				return fmt.Errorf("プロファイル %s はどの .toolversions にも定義されていません", profile)
+			}
+			return fmt.Errorf("プロファイル %s はどの .toolversions にも定義されていません (定義済み: %s)", profile, strings.Join(tv.Profiles, ", "))
 		}
-		absDir = parent
 	}
+	return nil
+}
 
-	return "", fmt.Errorf("%s が見つかりません (%s から / まで検索)", config.ToolVersionFile, dir)
+// 実際に使われたプロファイルを返す（ARSENAL_PROFILE のうち定義されていないものは除く）
+func activeProfiles(tv *ToolVersions, profiles []string) []string {
+	if profiles == nil {
+		profiles = ProfilesFromEnv()
+	}
+	var active []string
+	for _, p := range profiles {
+		if slices.Contains(tv.Profiles, p) {
+			active = append(active, p)
+		}
+	}
+	return active
 }
 
 // ファイルフォーマットを読み込む:
 //
 //	node 20.10.0
 //	go 1.22.0
-//	python 3.12.0
-func parseToolVersionsFile(path string) (*ToolVersions, error) {
-	f, err := os.Open(path)
+//	python 3.12.1 3.11.7
+//
+//	[ci]
+//	terraform 1.7.0
+func parseToolVersionsFile(path string, profiles []string) (*ToolVersions, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer func() { _ = f.Close() }()
 
-	tv := &ToolVersions{
-		Tools: make(map[string]string),
+	doc := ParseToolVersionsDocument(data)
+	if err := doc.Invalid(path); err != nil {
+		return nil, err
 	}
 
-	scanner := bufio.NewScanner(f)
-	lineNum := 0
-	for scanner.Scan() {
-		lineNum++
-		line := strings.TrimSpace(scanner.Text())
-
-		// 空行とコメントをスキップ
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
-		}
-
-		parts := strings.Fields(line)
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("%s:%d: '<ツール> <バージョン>' を期待、'%s' を取得", path, lineNum, line)
-		}
-
-		tv.Tools[parts[0]] = parts[1]
+	tv := doc.ToolVersions(profiles...)
+	for tool := range tv.Tools {
+		tv.Origins[tool] = path
 	}
-
-	return tv, scanner.Err()
+	for i := range tv.Shadowed {
+		tv.Shadowed[i].Origin = path
+	}
+	return tv, nil
 }
@@ -0,0 +1,309 @@
+package version
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// .toolversions をコメント、空行、行の順序を保ったまま編集するためのドキュメント
+// 編集は対象のツール行だけを書き換え、それ以外の行は元のまま出力する
+//
+// [ci] のような見出しから次の見出しまではプロファイルのセクションになる
+// Get / Set / Remove / Tools は見出しより前のベースの行だけを対象にする
+type ToolVersionsDocument struct {
+	lines           []tvLine
+	eol             string // 改行コード（元のファイルに合わせる）
+	trailingNewline bool   // 最終行の後に改行があるか
+}
+
+// .toolversions の 1 行を表す
+type tvLine struct {
+	text     string   // 改行を除いた元の行
+	section  string   // 属するプロファイル（ベースなら空）
+	header   bool     // [<プロファイル>] の見出し行
+	tool     string   // ツール行でなければ空
+	versions []string // 優先順のバージョン（先頭から探してインストール済みのものが使われる）
+	// text 内のバージョン部分の範囲（Set で置き換える）
+	versionStart, versionEnd int
+	// コメントでも空行でもなく、'<ツール> <バージョン>...' として読めない行
+	invalid bool
+}
+
+// プロファイル名に使える文字
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// .toolversions の内容を解析する
+// 読めない行もエラーにせず保持し、Invalid で確認できるようにする
+func ParseToolVersionsDocument(data []byte) *ToolVersionsDocument {
+	content := string(data)
+	doc := &ToolVersionsDocument{eol: "\n"}
+	if strings.Contains(content, "\r\n") {
+		doc.eol = "\r\n"
+	}
+	if content == "" {
+		return doc
+	}
+
+	doc.trailingNewline = strings.HasSuffix(content, "\n")
+	content = strings.TrimSuffix(content, "\n")
+
+	section := ""
+	for _, text := range strings.Split(content, "\n") {
+		line := parseTVLine(strings.TrimSuffix(text, "\r"))
+		if line.header {
+			section = line.section
+		}
+		line.section = section
+		doc.lines = append(doc.lines, line)
+	}
+	return doc
+}
+
+// ファイルから読み込む（存在しなければ空のドキュメントを返す）
+func LoadToolVersionsDocument(path string) (*ToolVersionsDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ParseToolVersionsDocument(nil), nil
+		}
+		return nil, err
+	}
+	return ParseToolVersionsDocument(data), nil
+}
+
+func parseTVLine(text string) tvLine {
+	line := tvLine{text: text}
+
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return line
+	}
+
+	// 行末コメント（空白の後の #）より前を対象にする
+	body := text
+	for i := 1; i < len(text); i++ {
+		if text[i] == '#' && (text[i-1] == ' ' || text[i-1] == '\t') {
+			body = text[:i]
+			break
+		}
+	}
+
+	// [<プロファイル>] の見出し
+	if head := strings.TrimSpace(body); strings.HasPrefix(head, "[") {
+		name := strings.TrimSuffix(strings.TrimPrefix(head, "["), "]")
+		if !strings.HasSuffix(head, "]") || !profileNamePattern.MatchString(name) {
+			line.invalid = true
+			return line
+		}
+		line.header = true
+		line.section = name
+		return line
+	}
+
+	type field struct{ start, end int }
+	var fields []field
+	for i := 0; i < len(body); {
+		for i < len(body) && (body[i] == ' ' || body[i] == '\t') {
+			i++
+		}
+		if i >= len(body) {
+			break
+		}
+		start := i
+		for i < len(body) && body[i] != ' ' && body[i] != '\t' {
+			i++
+		}
+		fields = append(fields, field{start, i})
+	}
+
+	if len(fields) < 2 {
+		line.invalid = true
+		return line
+	}
+
+	line.tool = body[fields[0].start:fields[0].end]
+	for _, f := range fields[1:] {
+		line.versions = append(line.versions, body[f.start:f.end])
+	}
+	line.versionStart = fields[1].start
+	line.versionEnd = fields[len(fields)-1].end
+	return line
+}
+
+// ベースのツールのバージョンを優先順で返す（同じツールが複数行ある場合は最後の行）
+func (d *ToolVersionsDocument) Get(tool string) ([]string, bool) {
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		if d.lines[i].section == "" && d.lines[i].tool == tool {
+			return d.lines[i].versions, true
+		}
+	}
+	return nil, false
+}
+
+// ベースのツールのバージョンを優先順で設定する
+// 既存の行はバージョン部分だけを置き換え（インデントや行末コメントは残す）、なければベースの末尾に追加する
+func (d *ToolVersionsDocument) Set(tool string, versions ...string) {
+	joined := strings.Join(versions, " ")
+
+	for i := len(d.lines) - 1; i >= 0; i-- {
+		line := &d.lines[i]
+		if line.section != "" || line.tool != tool {
+			continue
+		}
+		line.text = line.text[:line.versionStart] + joined + line.text[line.versionEnd:]
+		line.versionEnd = line.versionStart + len(joined)
+		line.versions = append([]string(nil), versions...)
+		return
+	}
+
+	line := parseTVLine(tool + " " + joined)
+	if pos := d.baseEnd(); pos < len(d.lines) {
+		d.lines = append(d.lines[:pos], append([]tvLine{line}, d.lines[pos:]...)...)
+		return
+	}
+
+	if len(d.lines) == 0 {
+		d.trailingNewline = true
+	}
+	d.lines = append(d.lines, line)
+}
+
+// ベースに行を追加する位置を返す
+// 最後のベースのツール行の次、なければ最初の見出しの前（直前の空行より前）、見出しがなければ末尾
+func (d *ToolVersionsDocument) baseEnd() int {
+	firstHeader := len(d.lines)
+	lastTool := -1
+	for i, line := range d.lines {
+		if line.header {
+			firstHeader = i
+			break
+		}
+		if line.tool != "" {
+			lastTool = i
+		}
+	}
+
+	if firstHeader == len(d.lines) {
+		return firstHeader
+	}
+	if lastTool >= 0 {
+		return lastTool + 1
+	}
+	pos := firstHeader
+	for pos > 0 && strings.TrimSpace(d.lines[pos-1].text) == "" {
+		pos--
+	}
+	return pos
+}
+
+// ベースのツールの行を全て削除する（削除した行がなければ false）
+func (d *ToolVersionsDocument) Remove(tool string) bool {
+	kept := d.lines[:0]
+	removed := false
+	for _, line := range d.lines {
+		if line.section == "" && line.tool == tool {
+			removed = true
+			continue
+		}
+		kept = append(kept, line)
+	}
+	d.lines = kept
+	return removed
+}
+
+// ベースに記載されている順でツール名を返す
+func (d *ToolVersionsDocument) Tools() []string {
+	seen := make(map[string]bool)
+	var tools []string
+	for _, line := range d.lines {
+		if line.section == "" && line.tool != "" && !seen[line.tool] {
+			seen[line.tool] = true
+			tools = append(tools, line.tool)
+		}
+	}
+	return tools
+}
+
+// '<ツール> <バージョン>...' として読めない行があればエラーを返す（path はメッセージ用）
+func (d *ToolVersionsDocument) Invalid(path string) error {
+	for i, line := range d.lines {
+		if line.invalid {
+			return fmt.Errorf("%s:%d: '<ツール> <バージョン>...' または '[<プロファイル>]' を期待、'%s' を取得", path, i+1, strings.TrimSpace(line.text))
+		}
+	}
+	return nil
+}
+
+// 記載されている順でプロファイル名を返す
+func (d *ToolVersionsDocument) Profiles() []string {
+	seen := make(map[string]bool)
+	var profiles []string
+	for _, line := range d.lines {
+		if line.header && !seen[line.section] {
+			seen[line.section] = true
+			profiles = append(profiles, line.section)
+		}
+	}
+	return profiles
+}
+
+// ベースに指定されたプロファイルを順に重ねたツールとバージョンの対応を返す
+// プロファイルで上書きされたベースの指定は Shadowed に入る（Origin は空）
+func (d *ToolVersionsDocument) ToolVersions(profiles ...string) *ToolVersions {
+	tv := &ToolVersions{
+		Tools:    make(map[string][]string),
+		Origins:  make(map[string]string),
+		Profiles: d.Profiles(),
+	}
+	fromProfile := make(map[string]string)
+
+	for _, section := range append([]string{""}, profiles...) {
+		for _, line := range d.lines {
+			if line.section != section || line.tool == "" {
+				continue
+			}
+			if prev, ok := tv.Tools[line.tool]; ok && section != "" && fromProfile[line.tool] != section {
+				tv.Shadowed = append(tv.Shadowed, ToolVersionsEntry{Tool: line.tool, Versions: prev, Profile: fromProfile[line.tool]})
+			}
+			tv.Tools[line.tool] = line.versions
+			fromProfile[line.tool] = section
+		}
+	}
+
+	for tool, section := range fromProfile {
+		if section != "" {
+			if tv.OriginProfiles == nil {
+				tv.OriginProfiles = make(map[string]string)
+			}
+			tv.OriginProfiles[tool] = section
+		}
+	}
+	return tv
+}
+
+// ドキュメントの内容を返す
+func (d *ToolVersionsDocument) Bytes() []byte {
+	var buf bytes.Buffer
+	for i, line := range d.lines {
+		if i > 0 {
+			buf.WriteString(d.eol)
+		}
+		buf.WriteString(line.text)
+	}
+	if len(d.lines) > 0 && d.trailingNewline {
+		buf.WriteString(d.eol)
+	}
+	return buf.Bytes()
+}
+
+// ファイルに書き込む（既存ファイルのパーミッションは保つ）
+func (d *ToolVersionsDocument) Save(path string) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(path, d.Bytes(), mode)
+}
@@ -0,0 +1,234 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// 編集が対象の行だけを書き換え、コメントや順序を保つかテストする
+func TestToolVersionsDocumentEdit(t *testing.T) {
+	original := "# プロジェクトのツール\n" +
+		"python 3.11.7\n" +
+		"\n" +
+		"node   20.10.0  # LTS\n" +
+		"go 1.22.0\n"
+
+	tests := []struct {
+		name string
+		edit func(d *ToolVersionsDocument)
+		want string
+	}{
+		{
+			name: "変更なし",
+			edit: func(d *ToolVersionsDocument) {},
+			want: original,
+		},
+		{
+			name: "既存の行を更新",
+			edit: func(d *ToolVersionsDocument) { d.Set("node", "20.11.0") },
+			want: strings.Replace(original, "node   20.10.0  # LTS", "node   20.11.0  # LTS", 1),
+		},
+		{
+			name: "新しいツールを末尾に追加",
+			edit: func(d *ToolVersionsDocument) { d.Set("rust", "1.75.0") },
+			want: original + "rust 1.75.0\n",
+		},
+		{
+			name: "ツールを削除",
+			edit: func(d *ToolVersionsDocument) { d.Remove("python") },
+			want: strings.Replace(original, "python 3.11.7\n", "", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := ParseToolVersionsDocument([]byte(original))
+			tt.edit(doc)
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("Bytes() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+// 改行コードと末尾の改行の有無が保たれるかテストする
+func TestToolVersionsDocumentLineEndings(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		want     string
+	}{
+		{"末尾の改行なし", "node 20.10.0", "node 20.10.0\ngo 1.22.0"},
+		{"CRLF", "# comment\r\nnode 20.10.0\r\n", "# comment\r\nnode 20.10.0\r\ngo 1.22.0\r\n"},
+		{"空のファイル", "", "go 1.22.0\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := ParseToolVersionsDocument([]byte(tt.original))
+			doc.Set("go", "1.22.0")
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Get と Tools が記載順と最後の指定を返すかテストする
+func TestToolVersionsDocumentGet(t *testing.T) {
+	doc := ParseToolVersionsDocument([]byte("node 18.19.0\ngo 1.22.0 # toolchain\nnode 20.10.0\n"))
+
+	if v, ok := doc.Get("node"); !ok || strings.Join(v, " ") != "20.10.0" {
+		t.Errorf("Get(node) = %q, %v, want [20.10.0]", v, ok)
+	}
+	if v, ok := doc.Get("go"); !ok || strings.Join(v, " ") != "1.22.0" {
+		t.Errorf("Get(go) = %q, %v, want [1.22.0]", v, ok)
+	}
+	if _, ok := doc.Get("python"); ok {
+		t.Error("Get(python) が見つかりました")
+	}
+	if got := strings.Join(doc.Tools(), ","); got != "node,go" {
+		t.Errorf("Tools() = %q, want node,go", got)
+	}
+
+	// 1 行に複数のバージョンを優先順で指定できる
+	doc = ParseToolVersionsDocument([]byte("python 3.12.1 3.11.7  # 3.11 も PATH に残す\n"))
+	if v, _ := doc.Get("python"); strings.Join(v, ",") != "3.12.1,3.11.7" {
+		t.Errorf("Get(python) = %q, want [3.12.1 3.11.7]", v)
+	}
+	doc.Set("python", "3.12.2", "3.11.7")
+	if got := string(doc.Bytes()); got != "python 3.12.2 3.11.7  # 3.11 も PATH に残す\n" {
+		t.Errorf("Bytes() = %q", got)
+	}
+
+	// 読めない行は保持され、Invalid で行番号付きのエラーになる
+	doc = ParseToolVersionsDocument([]byte("node 20.10.0\nbroken\n"))
+	if string(doc.Bytes()) != "node 20.10.0\nbroken\n" {
+		t.Errorf("Bytes() = %q", doc.Bytes())
+	}
+	if err := doc.Invalid(".toolversions"); err == nil || !strings.Contains(err.Error(), ".toolversions:2:") {
+		t.Errorf("Invalid() = %v", err)
+	}
+}
+
+// 存在しないファイルを読み込んで保存できるかテストする
+func TestToolVersionsDocumentLoadSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".toolversions")
+
+	doc, err := LoadToolVersionsDocument(path)
+	if err != nil {
+		t.Fatalf("LoadToolVersionsDocument() エラー: %v", err)
+	}
+	doc.Set("node", "20.10.0")
+	if err := doc.Save(path); err != nil {
+		t.Fatalf("Save() エラー: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ファイル読み込みエラー: %v", err)
+	}
+	if string(data) != "node 20.10.0\n" {
+		t.Errorf("ファイルの内容 = %q", data)
+	}
+}
+
+// プロファイルのセクションがベースの編集から除外されるかテストする
+func TestToolVersionsDocumentProfiles(t *testing.T) {
+	original := "node 20.10.0\n" +
+		"go 1.22.0\n" +
+		"\n" +
+		"[ci]\n" +
+		"terraform 1.7.0\n" +
+		"node 20.10.0 18.19.0\n" +
+		"\n" +
+		"[node18-compat]  # 旧バージョンでの確認\n" +
+		"node 18.19.0\n"
+
+	tests := []struct {
+		name string
+		edit func(d *ToolVersionsDocument)
+		want string
+	}{
+		{
+			name: "ベースの行を更新",
+			edit: func(d *ToolVersionsDocument) { d.Set("node", "22.0.0") },
+			want: strings.Replace(original, "node 20.10.0\ngo", "node 22.0.0\ngo", 1),
+		},
+		{
+			name: "新しいツールはベースの末尾に追加",
+			edit: func(d *ToolVersionsDocument) { d.Set("python", "3.12.1") },
+			want: strings.Replace(original, "go 1.22.0\n", "go 1.22.0\npython 3.12.1\n", 1),
+		},
+		{
+			name: "プロファイルにしかないツールはベースに追加",
+			edit: func(d *ToolVersionsDocument) { d.Set("terraform", "1.8.0") },
+			want: strings.Replace(original, "go 1.22.0\n", "go 1.22.0\nterraform 1.8.0\n", 1),
+		},
+		{
+			name: "ベースの行だけを削除",
+			edit: func(d *ToolVersionsDocument) { d.Remove("node") },
+			want: strings.Replace(original, "node 20.10.0\ngo", "go", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := ParseToolVersionsDocument([]byte(original))
+			tt.edit(doc)
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("Bytes() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+
+	doc := ParseToolVersionsDocument([]byte(original))
+	if err := doc.Invalid(".toolversions"); err != nil {
+		t.Errorf("Invalid() = %v", err)
+	}
+	if got := strings.Join(doc.Tools(), ","); got != "node,go" {
+		t.Errorf("Tools() = %q, want node,go", got)
+	}
+	if _, ok := doc.Get("terraform"); ok {
+		t.Error("Get(terraform) がプロファイルの行を返しました")
+	}
+	if got := strings.Join(doc.Profiles(), ","); got != "ci,node18-compat" {
+		t.Errorf("Profiles() = %q, want ci,node18-compat", got)
+	}
+
+	// ベースだけの場合
+	tv := doc.ToolVersions()
+	if want := map[string][]string{"node": {"20.10.0"}, "go": {"1.22.0"}}; !reflect.DeepEqual(tv.Tools, want) {
+		t.Errorf("ToolVersions() = %v, want %v", tv.Tools, want)
+	}
+
+	// プロファイルを重ねると追加・上書きされ、後のプロファイルほど優先される
+	tv = doc.ToolVersions("ci", "node18-compat")
+	want := map[string][]string{"node": {"18.19.0"}, "go": {"1.22.0"}, "terraform": {"1.7.0"}}
+	if !reflect.DeepEqual(tv.Tools, want) {
+		t.Errorf("ToolVersions(ci, node18-compat) = %v, want %v", tv.Tools, want)
+	}
+	if tv.OriginProfiles["node"] != "node18-compat" || tv.OriginProfiles["terraform"] != "ci" || tv.OriginProfiles["go"] != "" {
+		t.Errorf("OriginProfiles = %v", tv.OriginProfiles)
+	}
+	wantShadowed := []ToolVersionsEntry{
+		{Tool: "node", Versions: []string{"20.10.0"}},
+		{Tool: "node", Versions: []string{"20.10.0", "18.19.0"}, Profile: "ci"},
+	}
+	if !reflect.DeepEqual(tv.Shadowed, wantShadowed) {
+		t.Errorf("Shadowed = %+v, want %+v", tv.Shadowed, wantShadowed)
+	}
+}
+
+// 不正な見出しが Invalid で検出されるかテストする
+func TestToolVersionsDocumentInvalidHeader(t *testing.T) {
+	for _, content := range []string{"[]\n", "[ci\n", "[c i]\n", "[ci] node 20\n"} {
+		doc := ParseToolVersionsDocument([]byte(content))
+		if err := doc.Invalid(".toolversions"); err == nil {
+			t.Errorf("Invalid(%q) がエラーを返しませんでした", content)
+		}
+	}
+}
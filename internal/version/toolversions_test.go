@@ -1,11 +1,11 @@
 package version
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
-
-	"github.com/arsenal/internal/config"
 )
 
 // .toolversions ファイルのパースをテストする
@@ -19,31 +19,31 @@ node 20.10.0
 go 1.22.0
 
 # 別のコメント
-python 3.12.0
+python 3.12.0 3.11.7
 `
 	if err := os.WriteFile(tvFile, []byte(content), 0644); err != nil {
 		t.Fatalf("テストファイル作成エラー: %v", err)
 	}
 
-	tv, err := parseToolVersionsFile(tvFile)
+	tv, err := parseToolVersionsFile(tvFile, nil)
 	if err != nil {
 		t.Fatalf("parseToolVersionsFile() エラー: %v", err)
 	}
 
 	// 期待されるツールとバージョン
-	expected := map[string]string{
-		"node":   "20.10.0",
-		"go":     "1.22.0",
-		"python": "3.12.0",
+	expected := map[string][]string{
+		"node":   {"20.10.0"},
+		"go":     {"1.22.0"},
+		"python": {"3.12.0", "3.11.7"},
 	}
 
 	if len(tv.Tools) != len(expected) {
 		t.Errorf("ツール数 = %d, want %d", len(tv.Tools), len(expected))
 	}
 
-	for tool, version := range expected {
-		if tv.Tools[tool] != version {
-			t.Errorf("Tools[%q] = %q, want %q", tool, tv.Tools[tool], version)
+	for tool, versions := range expected {
+		if !reflect.DeepEqual(tv.Tools[tool], versions) {
+			t.Errorf("Tools[%q] = %q, want %q", tool, tv.Tools[tool], versions)
 		}
 	}
 }
@@ -59,8 +59,8 @@ func TestParseToolVersionsFileInvalid(t *testing.T) {
 			content: "node\n",
 		},
 		{
-			name:    "余分なフィールド",
-			content: "node 20.10.0 extra\n",
+			name:    "行末コメントのみ",
+			content: "node # 20.10.0\n",
 		},
 	}
 
@@ -73,7 +73,7 @@ func TestParseToolVersionsFileInvalid(t *testing.T) {
 				t.Fatalf("テストファイル作成エラー: %v", err)
 			}
 
-			_, err := parseToolVersionsFile(tvFile)
+			_, err := parseToolVersionsFile(tvFile, nil)
 			if err == nil {
 				t.Error("不正なフォーマットでエラーが返されませんでした")
 			}
@@ -81,43 +81,10 @@ func TestParseToolVersionsFileInvalid(t *testing.T) {
 	}
 }
 
-// .toolversions ファイルの書き込みをテストする
-func TestWriteToolVersions(t *testing.T) {
-	tmpDir := t.TempDir()
-
-	tv := &ToolVersions{
-		Tools: map[string]string{
-			"node":   "20.10.0",
-			"go":     "1.22.0",
-			"python": "3.12.0",
-		},
-	}
-
-	if err := WriteToolVersions(tmpDir, tv); err != nil {
-		t.Fatalf("WriteToolVersions() エラー: %v", err)
-	}
-
-	// ファイルが作成されているか確認
-	tvFile := filepath.Join(tmpDir, config.ToolVersionFile)
-	if _, err := os.Stat(tvFile); os.IsNotExist(err) {
-		t.Fatalf(".toolversions ファイルが作成されていません")
-	}
-
-	// ファイルを読み込んで検証
-	parsed, err := parseToolVersionsFile(tvFile)
-	if err != nil {
-		t.Fatalf("書き込んだファイルの読み込みエラー: %v", err)
-	}
-
-	for tool, version := range tv.Tools {
-		if parsed.Tools[tool] != version {
-			t.Errorf("Tools[%q] = %q, want %q", tool, parsed.Tools[tool], version)
-		}
-	}
-}
-
 // .toolversions ファイルの検索をテストする
-func TestFindToolVersionsFile(t *testing.T) {
+func TestReadToolVersionsFromSubDir(t *testing.T) {
+	m, _ := newTestManager(t, nil)
+
 	// ディレクトリ構造を作成
 	tmpDir := t.TempDir()
 	subDir := filepath.Join(tmpDir, "project", "src")
@@ -132,28 +99,30 @@ func TestFindToolVersionsFile(t *testing.T) {
 	}
 
 	// サブディレクトリから検索
-	found, err := findToolVersionsFile(subDir)
+	tv, err := m.ReadToolVersions(subDir, nil)
 	if err != nil {
-		t.Fatalf("findToolVersionsFile() エラー: %v", err)
+		t.Fatalf("ReadToolVersions() エラー: %v", err)
 	}
 
-	if found != tvFile {
-		t.Errorf("findToolVersionsFile() = %q, want %q", found, tvFile)
+	if tv.Origins["node"] != tvFile {
+		t.Errorf("Origins[node] = %q, want %q", tv.Origins["node"], tvFile)
 	}
 }
 
 // .toolversions が見つからない場合をテストする
-func TestFindToolVersionsFileNotFound(t *testing.T) {
+func TestReadToolVersionsNotFound(t *testing.T) {
+	m, _ := newTestManager(t, nil)
 	tmpDir := t.TempDir()
 
-	_, err := findToolVersionsFile(tmpDir)
-	if err == nil {
-		t.Error(".toolversions が存在しないのにエラーが返されませんでした")
+	_, err := m.ReadToolVersions(tmpDir, nil)
+	if !errors.Is(err, ErrToolVersionsNotFound) {
+		t.Errorf("ReadToolVersions() = %v, want ErrToolVersionsNotFound", err)
 	}
 }
 
 // ReadToolVersions 関数をテストする
 func TestReadToolVersions(t *testing.T) {
+	m, _ := newTestManager(t, nil)
 	tmpDir := t.TempDir()
 	tvFile := filepath.Join(tmpDir, ".toolversions")
 
@@ -162,20 +131,162 @@ func TestReadToolVersions(t *testing.T) {
 		t.Fatalf("テストファイル作成エラー: %v", err)
 	}
 
-	tv, path, err := ReadToolVersions(tmpDir)
+	tv, err := m.ReadToolVersions(tmpDir, nil)
 	if err != nil {
 		t.Fatalf("ReadToolVersions() エラー: %v", err)
 	}
 
-	if path != tvFile {
-		t.Errorf("path = %q, want %q", path, tvFile)
+	if !reflect.DeepEqual(tv.Files(), []string{tvFile}) {
+		t.Errorf("Files() = %q, want [%q]", tv.Files(), tvFile)
 	}
 
-	if tv.Tools["node"] != "20.10.0" {
+	if !reflect.DeepEqual(tv.Tools["node"], []string{"20.10.0"}) {
 		t.Errorf("node バージョンが正しくありません")
 	}
 
-	if tv.Tools["go"] != "1.22.0" {
+	if !reflect.DeepEqual(tv.Tools["go"], []string{"1.22.0"}) {
 		t.Errorf("go バージョンが正しくありません")
 	}
 }
+
+// 上位ディレクトリの .toolversions がツールごとに統合されるかテストする
+func TestReadToolVersionsMerge(t *testing.T) {
+	m, _ := newTestManager(t, nil)
+
+	root := t.TempDir()
+	frontend := filepath.Join(root, "frontend")
+	if err := os.MkdirAll(filepath.Join(frontend, "src"), 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+
+	rootFile := filepath.Join(root, ".toolversions")
+	if err := os.WriteFile(rootFile, []byte("node 18.19.0\ngo 1.22.0\npython 3.12.1\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+	frontendFile := filepath.Join(frontend, ".toolversions")
+	if err := os.WriteFile(frontendFile, []byte("node 20.10.0\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+
+	tv, err := m.ReadToolVersions(filepath.Join(frontend, "src"), nil)
+	if err != nil {
+		t.Fatalf("ReadToolVersions() エラー: %v", err)
+	}
+
+	wantTools := map[string][]string{
+		"node":   {"20.10.0"},
+		"go":     {"1.22.0"},
+		"python": {"3.12.1"},
+	}
+	if !reflect.DeepEqual(tv.Tools, wantTools) {
+		t.Errorf("Tools = %v, want %v", tv.Tools, wantTools)
+	}
+
+	wantOrigins := map[string]string{
+		"node":   frontendFile,
+		"go":     rootFile,
+		"python": rootFile,
+	}
+	if !reflect.DeepEqual(tv.Origins, wantOrigins) {
+		t.Errorf("Origins = %v, want %v", tv.Origins, wantOrigins)
+	}
+
+	wantShadowed := []ToolVersionsEntry{{Tool: "node", Versions: []string{"18.19.0"}, Origin: rootFile}}
+	if !reflect.DeepEqual(tv.Shadowed, wantShadowed) {
+		t.Errorf("Shadowed = %v, want %v", tv.Shadowed, wantShadowed)
+	}
+
+	if want := []string{rootFile, frontendFile}; !reflect.DeepEqual(tv.Files(), want) {
+		t.Errorf("Files() = %v, want %v", tv.Files(), want)
+	}
+}
+
+// 同じディレクトリの .tool-versions で上書きされた指定が記録されるかテストする
+func TestReadToolVersionsShadowedInSameDir(t *testing.T) {
+	m, _ := newTestManager(t, nil)
+	dir := t.TempDir()
+
+	tvFile := filepath.Join(dir, ".toolversions")
+	if err := os.WriteFile(tvFile, []byte("node 20.10.0\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+	asdfFile := filepath.Join(dir, ".tool-versions")
+	if err := os.WriteFile(asdfFile, []byte("node 18.19.0\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+
+	tv, err := m.ReadToolVersions(dir, nil)
+	if err != nil {
+		t.Fatalf("ReadToolVersions() エラー: %v", err)
+	}
+
+	if tv.Origins["node"] != tvFile {
+		t.Errorf("Origins[node] = %q, want %q", tv.Origins["node"], tvFile)
+	}
+	wantShadowed := []ToolVersionsEntry{{Tool: "node", Versions: []string{"18.19.0"}, Origin: asdfFile}}
+	if !reflect.DeepEqual(tv.Shadowed, wantShadowed) {
+		t.Errorf("Shadowed = %v, want %v", tv.Shadowed, wantShadowed)
+	}
+}
+
+// プロファイルの選択と ARSENAL_PROFILE をテストする
+func TestReadToolVersionsProfiles(t *testing.T) {
+	m, _ := newTestManager(t, nil)
+
+	root := t.TempDir()
+	service := filepath.Join(root, "service")
+	if err := os.MkdirAll(service, 0755); err != nil {
+		t.Fatalf("ディレクトリ作成エラー: %v", err)
+	}
+	rootFile := filepath.Join(root, ".toolversions")
+	if err := os.WriteFile(rootFile, []byte("go 1.22.0\n\n[ci]\nterraform 1.7.0\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+	serviceFile := filepath.Join(service, ".toolversions")
+	if err := os.WriteFile(serviceFile, []byte("node 20.10.0\n\n[node18-compat]\nnode 18.19.0\n"), 0644); err != nil {
+		t.Fatalf("テストファイル作成エラー: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		profiles []string
+		env      string
+		want     map[string][]string
+	}{
+		{"ベースのみ", []string{}, "ci", map[string][]string{"go": {"1.22.0"}, "node": {"20.10.0"}}},
+		{"ci", []string{"ci"}, "", map[string][]string{"go": {"1.22.0"}, "node": {"20.10.0"}, "terraform": {"1.7.0"}}},
+		{"node18-compat", []string{"node18-compat"}, "", map[string][]string{"go": {"1.22.0"}, "node": {"18.19.0"}}},
+		{"ARSENAL_PROFILE", nil, "ci, node18-compat", map[string][]string{"go": {"1.22.0"}, "node": {"18.19.0"}, "terraform": {"1.7.0"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(ProfileEnvVar, tt.env)
+
+			tv, err := m.ReadToolVersions(service, tt.profiles)
+			if err != nil {
+				t.Fatalf("ReadToolVersions() エラー: %v", err)
+			}
+			if !reflect.DeepEqual(tv.Tools, tt.want) {
+				t.Errorf("Tools = %v, want %v", tv.Tools, tt.want)
+			}
+			if want := []string{"node18-compat", "ci"}; !reflect.DeepEqual(tv.Profiles, want) {
+				t.Errorf("Profiles = %v, want %v", tv.Profiles, want)
+			}
+		})
+	}
+
+	tv, err := m.ReadToolVersions(service, []string{"ci"})
+	if err != nil {
+		t.Fatalf("ReadToolVersions() エラー: %v", err)
+	}
+	if tv.Origins["terraform"] != rootFile || tv.OriginProfiles["terraform"] != "ci" {
+		t.Errorf("terraform の指定元 = %q [%s]", tv.Origins["terraform"], tv.OriginProfiles["terraform"])
+	}
+	if err := tv.CheckProfiles([]string{"ci"}); err != nil {
+		t.Errorf("CheckProfiles(ci) = %v", err)
+	}
+	if err := tv.CheckProfiles([]string{"cd"}); err == nil {
+		t.Error("CheckProfiles(cd) がエラーを返しませんでした")
+	}
+}
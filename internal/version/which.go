@@ -0,0 +1,163 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// arsenal which の調査結果を表す
+type WhichResult struct {
+	Name string
+	// 実行ファイルを提供するツール（どのツールにもなければ空）
+	Tool string
+	// Tool のバージョン解決結果
+	Resolution Resolution
+	// 解決されたバージョン内の実行ファイルの絶対パス（解決されたバージョンになければ空）
+	Path string
+	// Path が属するバージョン（.toolversions のフォールバックから見つかった場合は Resolution.Version と異なる）
+	Version string
+	// PATH 上で Arsenal 管理のディレクトリより前にある同名の実行ファイル
+	Shadowing []string
+	// Arsenal 管理のディレクトリが PATH に含まれているか
+	OnPath bool
+}
+
+// dir で name を実行したときに使われる実行ファイルを調べる
+// 解決されたバージョンに name があるツールを優先し、なければインストール済みの
+// 他のバージョンに name があるツールを返す
+func (m *Manager) Which(dir, name string) (*WhichResult, error) {
+	resolutions, err := m.Resolve(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WhichResult{Name: name}
+
+	for _, r := range resolutions {
+		if !r.Installed {
+			continue
+		}
+		if path, ver, err := m.FindResolvedExecutable(r, name); err == nil {
+			result.Tool = r.Tool
+			result.Resolution = r
+			result.Path = path
+			result.Version = ver
+			break
+		}
+	}
+
+	if result.Tool == "" {
+		tool := m.executableOwner(name)
+		if tool == "" {
+			return result, nil
+		}
+		result.Tool = tool
+		result.Resolution = Resolution{Tool: tool}
+		for _, r := range resolutions {
+			if r.Tool == tool {
+				result.Resolution = r
+			}
+		}
+	}
+
+	result.Shadowing, result.OnPath = m.shadowingExecutables(result, os.Getenv("PATH"))
+	return result, nil
+}
+
+// インストール済みのいずれかのバージョンに name があるツールを返す（ツール名順で最初のもの）
+func (m *Manager) executableOwner(name string) string {
+	tools := m.registry.List()
+	sort.Strings(tools)
+
+	for _, tool := range tools {
+		versions, err := m.List(tool)
+		if err != nil {
+			continue
+		}
+		for _, ver := range versions {
+			if m.findExecutable(tool, ver, name) != "" {
+				return tool
+			}
+		}
+	}
+	return ""
+}
+
+// PATH を先頭から調べ、Arsenal 管理のディレクトリより前にある同名の実行ファイルを返す
+// 管理ディレクトリが PATH になければ見つかった全てを返し、第 2 戻り値は false になる
+func (m *Manager) shadowingExecutables(result *WhichResult, pathEnv string) ([]string, bool) {
+	owned := map[string]bool{
+		filepath.Clean(m.paths.Bin): true,
+	}
+	if m.paths.Shims != "" {
+		owned[filepath.Clean(m.paths.Shims)] = true
+	}
+	if result.Tool != "" {
+		for _, d := range m.CurrentBinDirs(result.Tool) {
+			owned[filepath.Clean(d)] = true
+		}
+		if result.Resolution.Version != "" {
+			for _, d := range m.resolutionBinDirs(result.Resolution) {
+				owned[filepath.Clean(d)] = true
+			}
+		}
+	}
+
+	var shadowing []string
+	seen := make(map[string]bool)
+	for _, entry := range filepath.SplitList(pathEnv) {
+		if entry == "" {
+			continue
+		}
+		entry = filepath.Clean(entry)
+		if owned[entry] {
+			return shadowing, true
+		}
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+
+		if path := findInDir(entry, result.Name); path != "" {
+			shadowing = append(shadowing, path)
+		}
+	}
+	return shadowing, false
+}
+
+// 指定バージョンの bin ディレクトリから name を探す（見つからなければ空）
+// Executables と同じく、プラグインの executables 許可リストに含まれないものは見つからない扱いにする
+func (m *Manager) findExecutable(toolName, version, name string) string {
+	p, err := m.registry.Get(toolName)
+	if err != nil {
+		return ""
+	}
+
+	for _, dir := range m.VersionBinDirs(toolName, version) {
+		if path := findInDir(dir, name); path != "" && p.ExposesExecutable(filepath.Base(path)) {
+			return path
+		}
+	}
+	return ""
+}
+
+// dir 内の実行ファイル name のパスを返す
+// Windows では拡張子を省略した名前も受け付ける
+func findInDir(dir, name string) string {
+	candidates := []string{name}
+	if runtime.GOOS == "windows" && filepath.Ext(name) == "" {
+		for _, ext := range []string{".exe", ".cmd", ".bat", ".ps1"} {
+			candidates = append(candidates, name+ext)
+		}
+	}
+
+	for _, c := range candidates {
+		path := filepath.Join(dir, c)
+		if isExecutable(path) {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/arsenal/internal/config"
+)
+
+// 解決されたバージョンの実行ファイルと PATH 上の先行するコピーを返すかテストする
+func TestManagerWhich(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, nil)
+
+	npm20 := filepath.Join(paths.ToolVersionPath("node", "20.10.0"), "bin", "npm")
+	writeExecutable(t, npm20)
+	writeExecutable(t, filepath.Join(paths.ToolVersionPath("node", "18.19.0"), "bin", "npm"))
+	if err := m.switchVersion("node", "18.19.0"); err != nil {
+		t.Fatalf("switchVersion() エラー: %v", err)
+	}
+
+	projectDir := t.TempDir()
+	tvPath := filepath.Join(projectDir, config.ToolVersionFile)
+	if err := os.WriteFile(tvPath, []byte("node 20.10.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	// Arsenal より前にシステムの npm がある
+	systemBin := t.TempDir()
+	systemNpm := filepath.Join(systemBin, "npm")
+	writeExecutable(t, systemNpm)
+	t.Setenv("PATH", systemBin+string(os.PathListSeparator)+paths.Bin+string(os.PathListSeparator)+t.TempDir())
+
+	result, err := m.Which(projectDir, "npm")
+	if err != nil {
+		t.Fatalf("Which() エラー: %v", err)
+	}
+	if result.Tool != "node" || result.Path != npm20 {
+		t.Errorf("Which() = %+v", result)
+	}
+	if result.Resolution.Source != SourceToolVersions || result.Resolution.Origin != tvPath {
+		t.Errorf("Resolution = %+v", result.Resolution)
+	}
+	if !result.OnPath || len(result.Shadowing) != 1 || result.Shadowing[0] != systemNpm {
+		t.Errorf("Shadowing = %v, OnPath = %v", result.Shadowing, result.OnPath)
+	}
+
+	// Arsenal のディレクトリが PATH になければ見つかったものを全て返す
+	t.Setenv("PATH", systemBin)
+	result, err = m.Which(t.TempDir(), "npm")
+	if err != nil {
+		t.Fatalf("Which() エラー: %v", err)
+	}
+	if result.Resolution.Source != SourceGlobal || result.Resolution.Version != "18.19.0" {
+		t.Errorf("Resolution = %+v", result.Resolution)
+	}
+	if result.OnPath || len(result.Shadowing) != 1 {
+		t.Errorf("Shadowing = %v, OnPath = %v", result.Shadowing, result.OnPath)
+	}
+}
+
+// 解決されたバージョンに実行ファイルがない場合に提供元のツールを返すかテストする
+func TestManagerWhichNotInResolvedVersion(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, nil)
+	writeExecutable(t, filepath.Join(paths.ToolVersionPath("node", "20.10.0"), "bin", "npm"))
+
+	// 未インストールの 18.19.0 が固定されている
+	projectDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(projectDir, config.ToolVersionFile), []byte("node 18.19.0\n"), 0644); err != nil {
+		t.Fatalf(".toolversions 作成エラー: %v", err)
+	}
+
+	result, err := m.Which(projectDir, "npm")
+	if err != nil {
+		t.Fatalf("Which() エラー: %v", err)
+	}
+	if result.Tool != "node" || result.Path != "" || result.Resolution.Version != "18.19.0" || result.Resolution.Installed {
+		t.Errorf("Which() = %+v", result)
+	}
+
+	// どのツールにもない実行ファイル
+	result, err = m.Which(projectDir, "unknown-binary")
+	if err != nil {
+		t.Fatalf("Which() エラー: %v", err)
+	}
+	if result.Tool != "" {
+		t.Errorf("Which(unknown-binary) = %+v", result)
+	}
+}
+
+// executables 許可リストにない実行ファイルが見つからない扱いになるかテストする
+func TestManagerFindResolvedExecutableAllowlist(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("実行ビットに依存するため Windows ではスキップ")
+	}
+
+	m, paths := newTestManager(t, map[string]string{"testrust.toml": multiBinPlugin})
+
+	rustDir := paths.ToolVersionPath("testrust", "1.75.0")
+	cargo := filepath.Join(rustDir, "cargo", "bin", "cargo")
+	writeExecutable(t, cargo)
+	writeExecutable(t, filepath.Join(rustDir, "cargo", "bin", "cargo-helper"))
+
+	r := Resolution{Tool: "testrust", Version: "1.75.0", Source: SourceToolVersions, Installed: true}
+	if path, _, err := m.FindResolvedExecutable(r, "cargo"); err != nil || path != cargo {
+		t.Errorf("FindResolvedExecutable(cargo) = %q, %v", path, err)
+	}
+	if path, _, err := m.FindResolvedExecutable(r, "cargo-helper"); err == nil {
+		t.Errorf("FindResolvedExecutable(cargo-helper) = %q, want error", path)
+	}
+
+	result, err := m.Which(t.TempDir(), "cargo-helper")
+	if err != nil {
+		t.Fatalf("Which() エラー: %v", err)
+	}
+	if result.Tool != "" {
+		t.Errorf("Which(cargo-helper) = %+v", result)
+	}
+}